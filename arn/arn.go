@@ -0,0 +1,205 @@
+/*
+Package arn parses, validates and matches [Amazon Resource Names].
+
+An ARN has the form
+
+	arn:partition:service:region:account-id:resource
+
+where the resource is a resource ID, or a resource type and resource ID
+separated by a slash or a colon, depending on the service:
+
+	arn:aws:s3:::examplebucket/logs/1.txt
+	arn:aws:iam::123456789012:role/application/my-role
+	arn:aws:lambda:us-east-1:123456789012:function:my-function:prod
+
+[Amazon Resource Names]: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference-arns.html
+*/
+package arn
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+)
+
+const (
+	ErrorInvalidPrefix     = "ARN must start with \"arn:\""
+	ErrorInvalidSections   = "ARN must have 6 colon-separated sections"
+	ErrorInvalidPartition  = "invalid ARN partition"
+	ErrorInvalidService    = "invalid ARN service"
+	ErrorInvalidRegion     = "invalid ARN region"
+	ErrorInvalidAccountID  = "invalid ARN account ID"
+	ErrorEmptyResource     = "ARN resource is empty"
+	ErrorContainsWildcards = "ARN contains wildcards"
+
+	prefix   = "arn"
+	sections = 6
+)
+
+// Resource delimiters.
+const (
+	DelimiterNone  = ""
+	DelimiterSlash = "/"
+	DelimiterColon = ":"
+)
+
+var (
+	partitionRegex = regexp.MustCompile(`^aws(-[a-z]+)*$`)
+	serviceRegex   = regexp.MustCompile(`^[a-z0-9][a-z0-9-.]*$`)
+	regionRegex    = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	accountRegex   = regexp.MustCompile(`^(\d{12}|aws)$`)
+)
+
+// untypedServices are services whose resources are not prefixed by a
+// resource type, so a slash or colon is part of the resource ID.
+var untypedServices = map[string]bool{
+	"s3":  true,
+	"sns": true,
+	"sqs": true,
+}
+
+// ARN is a parsed Amazon Resource Name.
+type ARN struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountID string
+	// Resource is the full resource section of the ARN.
+	Resource string
+	// ResourceType is the resource type, such as "role" or "function", or
+	// empty if the resource has no type.
+	ResourceType string
+	// ResourceID is the resource section after the resource type.
+	ResourceID string
+	// Delimiter is the character separating the resource type from the
+	// resource ID, or DelimiterNone if the resource has no type.
+	Delimiter string
+}
+
+// Parse splits an ARN into its sections. It only checks that the ARN has the
+// right number of sections, so it can be used for ARN patterns with wildcards.
+// Use Validate to check the sections of a concrete ARN.
+func Parse(s string) (ARN, error) {
+	if !strings.HasPrefix(s, prefix+":") {
+		return ARN{}, errors.New(ErrorInvalidPrefix)
+	}
+	parts := strings.SplitN(s, ":", sections)
+	if len(parts) != sections {
+		return ARN{}, errors.New(ErrorInvalidSections)
+	}
+	a := ARN{
+		Partition: parts[1],
+		Service:   parts[2],
+		Region:    parts[3],
+		AccountID: parts[4],
+		Resource:  parts[5],
+	}
+	a.ResourceType, a.ResourceID, a.Delimiter = splitResource(a.Service, a.Resource)
+	return a, nil
+}
+
+// splitResource splits a resource section at the first slash or colon.
+func splitResource(service, resource string) (string, string, string) {
+	if untypedServices[service] {
+		return "", resource, DelimiterNone
+	}
+	i := strings.IndexAny(resource, DelimiterSlash+DelimiterColon)
+	if i < 0 {
+		return "", resource, DelimiterNone
+	}
+	return resource[:i], resource[i+1:], resource[i : i+1]
+}
+
+// String returns the ARN in its string form.
+func (a ARN) String() string {
+	resource := a.Resource
+	if resource == "" {
+		resource = a.ResourceType + a.Delimiter + a.ResourceID
+	}
+	return strings.Join([]string{prefix, a.Partition, a.Service, a.Region, a.AccountID, resource}, ":")
+}
+
+// Validate checks that each section of the ARN is well-formed. Wildcards are
+// not allowed.
+func (a ARN) Validate() error {
+	if wildcard.HasWildcard(a.String()) {
+		return errors.New(ErrorContainsWildcards)
+	}
+	if !partitionRegex.MatchString(a.Partition) {
+		return fmt.Errorf("%s %q", ErrorInvalidPartition, a.Partition)
+	}
+	if !serviceRegex.MatchString(a.Service) {
+		return fmt.Errorf("%s %q", ErrorInvalidService, a.Service)
+	}
+	if a.Region != "" && !regionRegex.MatchString(a.Region) {
+		return fmt.Errorf("%s %q", ErrorInvalidRegion, a.Region)
+	}
+	if a.AccountID != "" && !accountRegex.MatchString(a.AccountID) {
+		return fmt.Errorf("%s %q", ErrorInvalidAccountID, a.AccountID)
+	}
+	if a.Resource == "" {
+		return errors.New(ErrorEmptyResource)
+	}
+	return nil
+}
+
+// Validate parses and validates an ARN.
+func Validate(s string) error {
+	a, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	return a.Validate()
+}
+
+// IsARN returns true if the string has the structure of an ARN.
+func IsARN(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Match reports whether an ARN matches an ARN pattern, such as a value from
+// a policy's Resource element. The pattern may contain '*' and '?' wildcards,
+// which are matched within each of the first five sections, so a wildcard in
+// the region never matches across a colon into the account ID. Wildcards in
+// the resource section may match colons and slashes. A pattern that is not an
+// ARN, such as "*", is matched against the whole value.
+func Match(pattern, value string) bool {
+	want := strings.SplitN(pattern, ":", sections)
+	if len(want) != sections {
+		return wildcard.Match(pattern, value)
+	}
+	got := strings.SplitN(value, ":", sections)
+	if len(got) != sections {
+		return false
+	}
+	for i := range want {
+		if !wildcard.Match(want[i], got[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// AccountID returns the account ID of an AWS principal given either as an
+// account ID or as an ARN, such as a role ARN or account root ARN. It returns
+// an empty string if the principal does not identify an account.
+func AccountID(principal string) string {
+	if accountRegex.MatchString(principal) && principal != "aws" {
+		return principal
+	}
+	a, err := Parse(principal)
+	if err != nil || !accountRegex.MatchString(a.AccountID) || a.AccountID == "aws" {
+		return ""
+	}
+	return a.AccountID
+}
+
+// IsAccountRoot returns true if the ARN is an account root principal, such
+// as "arn:aws:iam::123456789012:root".
+func (a ARN) IsAccountRoot() bool {
+	return a.Service == "iam" && a.Resource == "root" && a.AccountID != ""
+}
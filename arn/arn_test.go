@@ -0,0 +1,168 @@
+package arn
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    ARN
+		wantErr string
+	}{
+		{
+			name: "S3Object",
+			in:   "arn:aws:s3:::examplebucket/logs/1.txt",
+			want: ARN{Partition: "aws", Service: "s3", Resource: "examplebucket/logs/1.txt", ResourceID: "examplebucket/logs/1.txt"},
+		},
+		{
+			name: "IAMRoleWithPath",
+			in:   "arn:aws:iam::123456789012:role/application/my-role",
+			want: ARN{Partition: "aws", Service: "iam", AccountID: "123456789012", Resource: "role/application/my-role", ResourceType: "role", ResourceID: "application/my-role", Delimiter: DelimiterSlash},
+		},
+		{
+			name: "LambdaAlias",
+			in:   "arn:aws:lambda:us-east-1:123456789012:function:my-function:prod",
+			want: ARN{Partition: "aws", Service: "lambda", Region: "us-east-1", AccountID: "123456789012", Resource: "function:my-function:prod", ResourceType: "function", ResourceID: "my-function:prod", Delimiter: DelimiterColon},
+		},
+		{
+			name: "SNSTopic",
+			in:   "arn:aws-cn:sns:cn-north-1:123456789012:my-topic",
+			want: ARN{Partition: "aws-cn", Service: "sns", Region: "cn-north-1", AccountID: "123456789012", Resource: "my-topic", ResourceID: "my-topic"},
+		},
+		{
+			name: "AccountRoot",
+			in:   "arn:aws:iam::123456789012:root",
+			want: ARN{Partition: "aws", Service: "iam", AccountID: "123456789012", Resource: "root", ResourceID: "root"},
+		},
+		{
+			name: "Pattern",
+			in:   "arn:aws:ec2:*:*:instance/*",
+			want: ARN{Partition: "aws", Service: "ec2", Region: "*", AccountID: "*", Resource: "instance/*", ResourceType: "instance", ResourceID: "*", Delimiter: DelimiterSlash},
+		},
+		{
+			name:    "NotAnARN",
+			in:      "*",
+			wantErr: ErrorInvalidPrefix,
+		},
+		{
+			name:    "TooFewSections",
+			in:      "arn:aws:s3:examplebucket",
+			wantErr: ErrorInvalidSections,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.in)
+			if err != nil {
+				if tc.wantErr == "" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err.Error() != tc.wantErr {
+					t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if tc.wantErr != "" {
+				t.Fatalf("expected error, got nil")
+			}
+			if got != tc.want {
+				t.Errorf("got '%+v', want '%+v'", got, tc.want)
+			}
+			if got.String() != tc.in {
+				t.Errorf("got '%s', want '%s'", got.String(), tc.in)
+			}
+		})
+	}
+}
+
+func TestStringFromParts(t *testing.T) {
+	a := ARN{Partition: "aws", Service: "iam", AccountID: "123456789012", ResourceType: "user", ResourceID: "Bob", Delimiter: DelimiterSlash}
+	want := "arn:aws:iam::123456789012:user/Bob"
+	if a.String() != want {
+		t.Errorf("got '%s', want '%s'", a.String(), want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{name: "S3", in: "arn:aws:s3:::examplebucket"},
+		{name: "ManagedPolicy", in: "arn:aws:iam::aws:policy/ReadOnlyAccess"},
+		{name: "GovCloud", in: "arn:aws-us-gov:ec2:us-gov-west-1:123456789012:instance/i-0123456789abcdef0"},
+		{name: "Wildcard", in: "arn:aws:s3:::examplebucket/*", wantErr: ErrorContainsWildcards},
+		{name: "Partition", in: "arn:azure:s3:::examplebucket", wantErr: `invalid ARN partition "azure"`},
+		{name: "Service", in: "arn:aws:S3:::examplebucket", wantErr: `invalid ARN service "S3"`},
+		{name: "Region", in: "arn:aws:sqs:useast1:123456789012:queue", wantErr: `invalid ARN region "useast1"`},
+		{name: "Account", in: "arn:aws:sqs:us-east-1:1234:queue", wantErr: `invalid ARN account ID "1234"`},
+		{name: "Resource", in: "arn:aws:sqs:us-east-1:123456789012:", wantErr: ErrorEmptyResource},
+		{name: "Prefix", in: "urn:aws:sqs:us-east-1:123456789012:queue", wantErr: ErrorInvalidPrefix},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.in)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if err.Error() != tc.wantErr {
+				t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		value   string
+		want    bool
+	}{
+		{name: "Star", pattern: "*", value: "arn:aws:s3:::examplebucket", want: true},
+		{name: "Exact", pattern: "arn:aws:s3:::examplebucket", value: "arn:aws:s3:::examplebucket", want: true},
+		{name: "ObjectWildcard", pattern: "arn:aws:s3:::examplebucket/*", value: "arn:aws:s3:::examplebucket/a/b.txt", want: true},
+		{name: "BucketNotObject", pattern: "arn:aws:s3:::examplebucket/*", value: "arn:aws:s3:::examplebucket", want: false},
+		{name: "RegionAndAccount", pattern: "arn:aws:ec2:*:*:instance/*", value: "arn:aws:ec2:us-east-1:123456789012:instance/i-1", want: true},
+		{name: "Question", pattern: "arn:aws:ec2:us-east-?:*:instance/*", value: "arn:aws:ec2:us-east-1:123456789012:instance/i-1", want: true},
+		{name: "NoCrossSection", pattern: "arn:aws:iam::*:role/x", value: "arn:aws:iam::1:2:role/x", want: false},
+		{name: "ResourceColon", pattern: "arn:aws:lambda:*:*:function:*", value: "arn:aws:lambda:us-east-1:123456789012:function:f:prod", want: true},
+		{name: "Case", pattern: "arn:aws:s3:::ExampleBucket", value: "arn:aws:s3:::examplebucket", want: false},
+		{name: "ValueNotARN", pattern: "arn:aws:s3:::*", value: "examplebucket", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Match(tc.pattern, tc.value); got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAccountID(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "123456789012", want: "123456789012"},
+		{in: "arn:aws:iam::123456789012:root", want: "123456789012"},
+		{in: "arn:aws:sts::123456789012:assumed-role/admin/session", want: "123456789012"},
+		{in: "arn:aws:iam::aws:policy/ReadOnlyAccess", want: ""},
+		{in: "*", want: ""},
+		{in: "aws", want: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			if got := AccountID(tc.in); got != tc.want {
+				t.Errorf("got '%s', want '%s'", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// ExpandActions replaces each action pattern, such as "s3:Get*" or
+// "ec2:Describe*", with the sorted list of catalog actions it matches. Known
+// actions are returned with the catalog's capitalization. Duplicate actions
+// are removed.
+//
+// Patterns the catalog cannot list every match of are kept as they are: "*",
+// patterns whose service part contains a wildcard, actions of services
+// missing from the catalog, and wildcard patterns of partial services.
+// Patterns that match no action in the catalog are also kept.
+func (c *Catalog) ExpandActions(actions *policy.StringOrSlice) *policy.StringOrSlice {
+	if actions == nil {
+		return nil
+	}
+	resp := []string{}
+	seen := map[string]bool{}
+	add := func(values ...string) {
+		for _, v := range values {
+			if !seen[strings.ToLower(v)] {
+				seen[strings.ToLower(v)] = true
+				resp = append(resp, v)
+			}
+		}
+	}
+	for _, pattern := range actions.Values() {
+		matches := c.matchActions(pattern)
+		if len(matches) == 0 {
+			add(pattern)
+			continue
+		}
+		add(matches...)
+	}
+	return policy.NewStringOrSlice(actions.IsSingular() && len(resp) == 1, resp...)
+}
+
+// matchActions returns the sorted names of the catalog actions that match an
+// action pattern, or nil if the pattern may match actions that are not in the
+// catalog.
+func (c *Catalog) matchActions(pattern string) []string {
+	prefix, actionPattern, ok := strings.Cut(pattern, ":")
+	if !ok || wildcard.HasWildcard(prefix) {
+		return nil
+	}
+	s := c.Service(prefix)
+	if s == nil || (s.Partial && wildcard.HasWildcard(actionPattern)) {
+		return nil
+	}
+	resp := []string{}
+	for _, a := range s.Actions {
+		if wildcard.MatchFold(actionPattern, a.Name) {
+			resp = append(resp, a.String())
+		}
+	}
+	sort.Strings(resp)
+	return resp
+}
+
+// CompressActions rewrites a list of actions into the smallest list of action
+// prefix patterns, such as "s3:GetObject*", that matches exactly the same
+// catalog actions. A pattern is only used when it matches more than one
+// action, and "service:*" is used when every action of a service is granted.
+// The returned actions are sorted.
+//
+// Patterns are only written for services whose catalog is complete. Patterns
+// that ExpandActions keeps, such as "*" or "custom:*", actions that are not in
+// the catalog, and the actions of partial services are kept as they are.
+//
+// The result is only equivalent to the input for the actions in the catalog.
+// Actions that AWS adds later may match the returned patterns.
+func (c *Catalog) CompressActions(actions *policy.StringOrSlice) *policy.StringOrSlice {
+	if actions == nil {
+		return nil
+	}
+	resp := []string{}
+	selected := map[string]map[string]bool{}
+	for _, action := range c.ExpandActions(actions).Values() {
+		a := c.Action(action)
+		if a == nil || a.service.Partial {
+			resp = append(resp, action)
+			continue
+		}
+		service := strings.ToLower(a.Service())
+		if selected[service] == nil {
+			selected[service] = map[string]bool{}
+		}
+		selected[service][strings.ToLower(a.Name)] = true
+	}
+	for service, names := range selected {
+		resp = append(resp, compressService(c.Service(service), names)...)
+	}
+	sort.Strings(resp)
+	return policy.NewStringOrSlice(actions.IsSingular() && len(resp) == 1, resp...)
+}
+
+// compressService returns the smallest set of prefix patterns covering the
+// selected actions of a service. Because the sets of actions matched by two
+// prefixes are either nested or disjoint, the smallest cover is formed by the
+// shortest prefix of each selected action that matches only selected actions.
+func compressService(s *Service, selected map[string]bool) []string {
+	actions := make([]*Action, len(s.Actions))
+	copy(actions, s.Actions)
+	sort.Slice(actions, func(i, j int) bool {
+		return strings.ToLower(actions[i].Name) < strings.ToLower(actions[j].Name)
+	})
+
+	resp := []string{}
+	covered := map[string]bool{}
+	for _, a := range actions {
+		name := strings.ToLower(a.Name)
+		if !selected[name] || covered[name] {
+			continue
+		}
+		// An action whose name is a prefix of an unselected action, such as
+		// sts:AssumeRole and sts:AssumeRoleWithSAML, can only be granted by
+		// its exact name.
+		pattern := a.String()
+		for l := 0; l <= len(name); l++ {
+			prefix := name[:l]
+			matches := []string{}
+			valid := true
+			for _, other := range actions {
+				otherName := strings.ToLower(other.Name)
+				if !strings.HasPrefix(otherName, prefix) {
+					continue
+				}
+				if !selected[otherName] {
+					valid = false
+					break
+				}
+				matches = append(matches, otherName)
+			}
+			if !valid {
+				continue
+			}
+			for _, m := range matches {
+				covered[m] = true
+			}
+			if len(matches) > 1 {
+				pattern = s.Name + ":" + a.Name[:l] + "*"
+			}
+			break
+		}
+		resp = append(resp, pattern)
+	}
+	return resp
+}
@@ -0,0 +1,249 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func TestExpandActions(t *testing.T) {
+	cases := []struct {
+		name string
+		in   *policy.StringOrSlice
+		want *policy.StringOrSlice
+	}{
+		{
+			name: "Prefix",
+			in:   policy.NewStringOrSlice(true, "sts:Get*"),
+			want: policy.NewStringOrSlice(false,
+				"sts:GetAccessKeyInfo",
+				"sts:GetCallerIdentity",
+				"sts:GetFederationToken",
+				"sts:GetServiceBearerToken",
+				"sts:GetSessionToken",
+			),
+		},
+		{
+			name: "CaseInsensitive",
+			in:   policy.NewStringOrSlice(true, "SQS:*queue"),
+			want: policy.NewStringOrSlice(false,
+				"sqs:CreateQueue",
+				"sqs:DeleteQueue",
+				"sqs:PurgeQueue",
+				"sqs:TagQueue",
+				"sqs:UntagQueue",
+			),
+		},
+		{
+			name: "SingleMatchKeepsSingular",
+			in:   policy.NewStringOrSlice(true, "s3:getobject"),
+			want: policy.NewStringOrSlice(true, "s3:GetObject"),
+		},
+		{
+			name: "UnknownAndDuplicates",
+			in:   policy.NewStringOrSlice(false, "sts:AssumeRole*", "sts:AssumeRole", "s4:GetObject", "sts:Nothing*"),
+			want: policy.NewStringOrSlice(false,
+				"sts:AssumeRole",
+				"sts:AssumeRoleWithSAML",
+				"sts:AssumeRoleWithWebIdentity",
+				"s4:GetObject",
+				"sts:Nothing*",
+			),
+		},
+		{
+			name: "Nil",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := completeCatalog(t).ExpandActions(tc.in)
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(policy.StringOrSlice{})); diff != "" {
+				t.Errorf("unexpected actions: %s", diff)
+			}
+		})
+	}
+}
+
+func TestExpandActionsPartial(t *testing.T) {
+	cases := []struct {
+		name    string
+		catalog *Catalog
+		in      *policy.StringOrSlice
+		want    *policy.StringOrSlice
+	}{
+		{
+			name:    "All",
+			catalog: completeCatalog(t),
+			in:      policy.NewStringOrSlice(true, "*"),
+			want:    policy.NewStringOrSlice(true, "*"),
+		},
+		{
+			name:    "ServiceWildcard",
+			catalog: completeCatalog(t),
+			in:      policy.NewStringOrSlice(false, "s*:GetCallerIdentity", "dynamodb:*"),
+			want:    policy.NewStringOrSlice(false, "s*:GetCallerIdentity", "dynamodb:*"),
+		},
+		{
+			name:    "PartialService",
+			catalog: Default(),
+			in:      policy.NewStringOrSlice(false, "ec2:Describe*", "ec2:describeinstances", "ec2:RunInstances"),
+			want:    policy.NewStringOrSlice(false, "ec2:Describe*", "ec2:DescribeInstances", "ec2:RunInstances"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.catalog.ExpandActions(tc.in)
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(policy.StringOrSlice{})); diff != "" {
+				t.Errorf("unexpected actions: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCompressActions(t *testing.T) {
+	cases := []struct {
+		name string
+		in   *policy.StringOrSlice
+		want *policy.StringOrSlice
+	}{
+		{
+			name: "WholeService",
+			in: policy.NewStringOrSlice(false,
+				"sts:AssumeRole",
+				"sts:AssumeRoleWithSAML",
+				"sts:AssumeRoleWithWebIdentity",
+				"sts:DecodeAuthorizationMessage",
+				"sts:GetAccessKeyInfo",
+				"sts:GetCallerIdentity",
+				"sts:GetFederationToken",
+				"sts:GetServiceBearerToken",
+				"sts:GetSessionToken",
+				"sts:SetSourceIdentity",
+				"sts:TagSession",
+			),
+			want: policy.NewStringOrSlice(false, "sts:*"),
+		},
+		{
+			name: "Prefixes",
+			in: policy.NewStringOrSlice(false,
+				"sts:AssumeRole",
+				"sts:AssumeRoleWithSAML",
+				"sts:AssumeRoleWithWebIdentity",
+				"sts:GetAccessKeyInfo",
+				"sts:GetCallerIdentity",
+				"sts:GetFederationToken",
+				"sts:GetServiceBearerToken",
+				"sts:GetSessionToken",
+				"sts:TagSession",
+			),
+			want: policy.NewStringOrSlice(false, "sts:A*", "sts:G*", "sts:TagSession"),
+		},
+		{
+			name: "NoCommonPrefix",
+			in:   policy.NewStringOrSlice(false, "sts:AssumeRole", "sts:GetCallerIdentity"),
+			want: policy.NewStringOrSlice(false, "sts:AssumeRole", "sts:GetCallerIdentity"),
+		},
+		{
+			name: "ExcludesSibling",
+			in: policy.NewStringOrSlice(false,
+				"sts:AssumeRole",
+				"sts:AssumeRoleWithSAML",
+			),
+			want: policy.NewStringOrSlice(false, "sts:AssumeRole", "sts:AssumeRoleWithSAML"),
+		},
+		{
+			name: "WildcardsAndUnknown",
+			in:   policy.NewStringOrSlice(false, "sqs:*Queue", "sqs:ListQueues", "sqs:ListQueueTags", "custom:Action"),
+			want: policy.NewStringOrSlice(false, "custom:Action", "sqs:CreateQueue", "sqs:DeleteQueue", "sqs:ListQ*", "sqs:PurgeQueue", "sqs:TagQueue", "sqs:UntagQueue"),
+		},
+		{
+			name: "Singular",
+			in:   policy.NewStringOrSlice(true, "sts:Get*"),
+			want: policy.NewStringOrSlice(true, "sts:G*"),
+		},
+		{
+			name: "Nil",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := completeCatalog(t).CompressActions(tc.in)
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(policy.StringOrSlice{})); diff != "" {
+				t.Errorf("unexpected actions: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCompressActionsPartial(t *testing.T) {
+	cases := []struct {
+		name    string
+		catalog *Catalog
+		in      *policy.StringOrSlice
+		want    *policy.StringOrSlice
+	}{
+		{
+			name:    "PartialService",
+			catalog: Default(),
+			in:      policy.NewStringOrSlice(false, "ec2:GetConsoleOutput", "ec2:GetPasswordData"),
+			want:    policy.NewStringOrSlice(false, "ec2:GetConsoleOutput", "ec2:GetPasswordData"),
+		},
+		{
+			name:    "PartialServiceWildcard",
+			catalog: Default(),
+			in:      policy.NewStringOrSlice(false, "sts:Get*", "sts:AssumeRole"),
+			want:    policy.NewStringOrSlice(false, "sts:AssumeRole", "sts:Get*"),
+		},
+		{
+			name:    "All",
+			catalog: completeCatalog(t),
+			in:      policy.NewStringOrSlice(true, "*"),
+			want:    policy.NewStringOrSlice(true, "*"),
+		},
+		{
+			name:    "ServiceWildcard",
+			catalog: completeCatalog(t),
+			in:      policy.NewStringOrSlice(false, "*:Get*", "dynamodb:GetItem", "sts:GetCallerIdentity", "sts:GetSessionToken"),
+			want:    policy.NewStringOrSlice(false, "*:Get*", "dynamodb:GetItem", "sts:GetCallerIdentity", "sts:GetSessionToken"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.catalog.CompressActions(tc.in)
+			if diff := cmp.Diff(got, tc.want, cmp.AllowUnexported(policy.StringOrSlice{})); diff != "" {
+				t.Errorf("unexpected actions: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCompressActionsRoundTrip(t *testing.T) {
+	for _, pattern := range []string{"s3:Get*", "s3:*Object*", "iam:*Role*", "ec2:Describe*", "kms:*"} {
+		t.Run(pattern, func(t *testing.T) {
+			c := completeCatalog(t)
+			in := c.ExpandActions(policy.NewStringOrSlice(false, pattern))
+			compressed := c.CompressActions(in)
+			got := c.ExpandActions(compressed)
+			if diff := cmp.Diff(got.Values(), in.Values()); diff != "" {
+				t.Errorf("compressed actions '%v' grant different actions: %s", compressed.Values(), diff)
+			}
+			if len(compressed.Values()) > len(in.Values()) {
+				t.Errorf("got %d actions, want at most %d", len(compressed.Values()), len(in.Values()))
+			}
+		})
+	}
+}
+
+// completeCatalog returns a copy of the embedded catalog whose services are
+// not marked as partial.
+func completeCatalog(t *testing.T) *Catalog {
+	c, err := loadFS(data, "data")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range c.Services() {
+		c.Service(name).Partial = false
+	}
+	return c
+}
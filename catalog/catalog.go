@@ -0,0 +1,234 @@
+/*
+Package catalog describes the actions, resource types and condition keys of AWS
+services, as published in the [Service Authorization Reference].
+
+The package embeds a partial snapshot of the reference for commonly used
+services, available from Default. It lists only a subset of the actions of
+each service, and each of its services is marked as Partial. Services are
+stored in the same JSON format that AWS publishes, so the complete reference
+can be embedded with "make update-catalog", or downloaded and loaded with
+LoadFile or LoadDir without network access at runtime:
+
+	c, err := catalog.LoadDir("./service-reference")
+	if err != nil {
+		panic(err)
+	}
+	a := c.Action("s3:PutObject")
+	fmt.Println(a.AccessLevel()) // Write
+
+[Service Authorization Reference]: https://docs.aws.amazon.com/service-authorization/latest/reference/reference.html
+*/
+package catalog
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ErrorMissingServiceName = "service reference is missing a Name"
+	ErrorDecodingReference  = "error decoding service reference"
+)
+
+// Access levels, as shown in the Service Authorization Reference.
+const (
+	AccessLevelList                  = "List"
+	AccessLevelRead                  = "Read"
+	AccessLevelWrite                 = "Write"
+	AccessLevelPermissionsManagement = "Permissions management"
+	AccessLevelTagging               = "Tagging"
+)
+
+//go:embed data/*.json
+var data embed.FS
+
+var (
+	defaultOnce    sync.Once
+	defaultCatalog *Catalog
+)
+
+// Default returns the catalog embedded in this package. The returned catalog
+// is shared and must not be modified; use Clone to get a copy that can be
+// updated.
+func Default() *Catalog {
+	defaultOnce.Do(func() {
+		c, err := loadFS(data, "data")
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded catalog: %v", err))
+		}
+		defaultCatalog = c
+	})
+	return defaultCatalog
+}
+
+// Catalog is a set of services.
+type Catalog struct {
+	services map[string]*Service
+}
+
+// New returns a catalog containing the given services.
+func New(services ...*Service) *Catalog {
+	c := &Catalog{services: map[string]*Service{}}
+	c.Add(services...)
+	return c
+}
+
+// Add adds services to the catalog, replacing any service with the same name.
+func (c *Catalog) Add(services ...*Service) {
+	for _, s := range services {
+		s.index()
+		c.services[strings.ToLower(s.Name)] = s
+	}
+}
+
+// Merge adds all services from another catalog, replacing any service with
+// the same name. This is used to refresh part of a catalog from a newer copy
+// of the reference.
+func (c *Catalog) Merge(other *Catalog) {
+	for _, s := range other.services {
+		c.services[strings.ToLower(s.Name)] = s
+	}
+}
+
+// Clone returns a copy of the catalog that can be modified without changing
+// the original. Services are shared between the two catalogs.
+func (c *Catalog) Clone() *Catalog {
+	clone := &Catalog{services: make(map[string]*Service, len(c.services))}
+	for k, s := range c.services {
+		clone.services[k] = s
+	}
+	return clone
+}
+
+// Services returns the sorted service prefixes in the catalog.
+func (c *Catalog) Services() []string {
+	resp := make([]string, 0, len(c.services))
+	for _, s := range c.services {
+		resp = append(resp, s.Name)
+	}
+	sort.Strings(resp)
+	return resp
+}
+
+// Service returns the service with the given prefix, or nil if the catalog
+// does not contain it. Prefixes are case-insensitive.
+func (c *Catalog) Service(prefix string) *Service {
+	return c.services[strings.ToLower(prefix)]
+}
+
+// Action returns the action with the given name, such as "s3:GetObject", or
+// nil if the catalog does not contain it. Action names are case-insensitive.
+func (c *Catalog) Action(name string) *Action {
+	prefix, action, ok := strings.Cut(name, ":")
+	if !ok {
+		return nil
+	}
+	s := c.Service(prefix)
+	if s == nil {
+		return nil
+	}
+	return s.Action(action)
+}
+
+// ConditionKey returns the service-specific condition key with the given
+// name, such as "s3:prefix" or "kms:EncryptionContext:Department", or nil if
+// the catalog does not contain it.
+func (c *Catalog) ConditionKey(name string) *ConditionKey {
+	prefix, _, ok := strings.Cut(name, ":")
+	if !ok {
+		return nil
+	}
+	s := c.Service(prefix)
+	if s == nil {
+		return nil
+	}
+	return s.ConditionKey(name)
+}
+
+// Load reads a catalog from a service reference document, or from a JSON
+// array of service reference documents.
+func Load(r io.Reader) (*Catalog, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	services, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return New(services...), nil
+}
+
+// LoadFile reads a catalog from a file. See Load for the accepted formats.
+func LoadFile(filename string) (*Catalog, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return c, nil
+}
+
+// LoadDir reads a catalog from every .json file in a directory, such as a
+// directory of service reference documents downloaded with
+// "make update-catalog".
+func LoadDir(dir string) (*Catalog, error) {
+	return loadFS(os.DirFS(dir), ".")
+}
+
+func loadFS(fsys fs.FS, dir string) (*Catalog, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	c := New()
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		services, err := decode(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		c.Add(services...)
+	}
+	return c, nil
+}
+
+// decode decodes a single service reference document or an array of them.
+func decode(b []byte) ([]*Service, error) {
+	var services []*Service
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		if err := json.Unmarshal(b, &services); err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrorDecodingReference, err)
+		}
+	} else {
+		s := &Service{}
+		if err := json.Unmarshal(b, s); err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrorDecodingReference, err)
+		}
+		services = append(services, s)
+	}
+	for _, s := range services {
+		if s == nil || s.Name == "" {
+			return nil, errors.New(ErrorMissingServiceName)
+		}
+	}
+	return services, nil
+}
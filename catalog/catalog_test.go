@@ -0,0 +1,204 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	want := []string{"ec2", "iam", "kms", "lambda", "s3", "sns", "sqs", "sts"}
+	if diff := cmp.Diff(c.Services(), want); diff != "" {
+		t.Errorf("unexpected services: %s", diff)
+	}
+	for _, name := range c.Services() {
+		s := c.Service(name)
+		for _, a := range s.Actions {
+			for _, r := range a.Resources {
+				if s.Resource(r.Name) == nil {
+					t.Errorf("%s references unknown resource type %q", a, r.Name)
+				}
+			}
+			for _, k := range a.ActionConditionKeys {
+				if s.ConditionKey(k) == nil {
+					t.Errorf("%s references unknown condition key %q", a, k)
+				}
+			}
+		}
+	}
+}
+
+func TestCatalogAction(t *testing.T) {
+	cases := []struct {
+		name        string
+		in          string
+		want        string
+		accessLevel string
+	}{
+		{name: "Write", in: "s3:PutObject", want: "s3:PutObject", accessLevel: AccessLevelWrite},
+		{name: "Read", in: "s3:GetObject", want: "s3:GetObject", accessLevel: AccessLevelRead},
+		{name: "List", in: "s3:ListBucket", want: "s3:ListBucket", accessLevel: AccessLevelList},
+		{name: "PermissionsManagement", in: "iam:PutRolePolicy", want: "iam:PutRolePolicy", accessLevel: AccessLevelPermissionsManagement},
+		{name: "Tagging", in: "iam:TagRole", want: "iam:TagRole", accessLevel: AccessLevelTagging},
+		{name: "CaseInsensitive", in: "STS:assumerole", want: "sts:AssumeRole", accessLevel: AccessLevelWrite},
+		{name: "UnknownAction", in: "s3:GetObjects"},
+		{name: "UnknownService", in: "s4:GetObject"},
+		{name: "NoPrefix", in: "GetObject"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Default().Action(tc.in)
+			if a == nil {
+				if tc.want != "" {
+					t.Fatalf("got nil, want '%s'", tc.want)
+				}
+				return
+			}
+			if a.String() != tc.want {
+				t.Errorf("got '%s', want '%s'", a.String(), tc.want)
+			}
+			if a.AccessLevel() != tc.accessLevel {
+				t.Errorf("got '%s', want '%s'", a.AccessLevel(), tc.accessLevel)
+			}
+		})
+	}
+}
+
+func TestCatalogConditionKey(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "s3:prefix", want: "s3:prefix"},
+		{in: "S3:Prefix", want: "s3:prefix"},
+		{in: "s3:ExistingObjectTag/team", want: "s3:ExistingObjectTag/${TagKey}"},
+		{in: "kms:EncryptionContext:Department", want: "kms:EncryptionContext:${EncryptionContextKey}"},
+		{in: "s3:NotAKey", want: ""},
+		{in: "aws:SourceIp", want: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got := ""
+			if k := Default().ConditionKey(tc.in); k != nil {
+				got = k.Name
+			}
+			if got != tc.want {
+				t.Errorf("got '%s', want '%s'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "Single",
+			in:   `{"Name": "example", "Actions": [{"Name": "DoThing"}]}`,
+			want: []string{"example"},
+		},
+		{
+			name: "Array",
+			in:   `[{"Name": "one"}, {"Name": "two"}]`,
+			want: []string{"one", "two"},
+		},
+		{
+			name:    "MissingName",
+			in:      `[{"Name": "one"}, {}]`,
+			wantErr: ErrorMissingServiceName,
+		},
+		{
+			name:    "Invalid",
+			in:      `{"Name": 1}`,
+			wantErr: "error decoding service reference: json: cannot unmarshal number into Go struct field Service.Name of type string",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := Load(strings.NewReader(tc.in))
+			if err != nil {
+				if tc.wantErr == "" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err.Error() != tc.wantErr {
+					t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if tc.wantErr != "" {
+				t.Fatalf("expected error, got nil")
+			}
+			if diff := cmp.Diff(c.Services(), tc.want); diff != "" {
+				t.Errorf("unexpected services: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadPartial(t *testing.T) {
+	c, err := Load(strings.NewReader(`[{"Name": "partial", "Partial": true}, {"Name": "complete"}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.Service("partial").Partial {
+		t.Errorf("got complete service, want partial")
+	}
+	if c.Service("complete").Partial {
+		t.Errorf("got partial service, want complete")
+	}
+}
+
+func TestLoadFileAndMerge(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "s3.json")
+	content := `{
+		"Name": "s3",
+		"Actions": [{
+			"Name": "GetShinyNewThing",
+			"Annotations": {"Properties": {"IsList": false, "IsPermissionManagement": false, "IsTaggingOnly": false, "IsWrite": false}}
+		}],
+		"Version": "v9.9"
+	}`
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := LoadFile(filename)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fromDir, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(fromDir.Services(), updated.Services()); diff != "" {
+		t.Errorf("unexpected services: %s", diff)
+	}
+
+	c := Default().Clone()
+	c.Merge(updated)
+	if a := c.Action("s3:GetShinyNewThing"); a == nil || a.AccessLevel() != AccessLevelRead {
+		t.Errorf("got '%v', want 's3:GetShinyNewThing'", a)
+	}
+	if a := c.Action("s3:GetObject"); a != nil {
+		t.Errorf("got '%s', want nil", a)
+	}
+	if c.Service("iam") == nil {
+		t.Errorf("got nil, want iam service")
+	}
+	if Default().Action("s3:GetShinyNewThing") != nil {
+		t.Errorf("merge modified the default catalog")
+	}
+
+	if _, err := LoadFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
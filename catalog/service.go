@@ -0,0 +1,211 @@
+package catalog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/arn"
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+)
+
+// Service is the authorization reference for a single AWS service.
+type Service struct {
+	// Name is the service prefix used in actions, such as "s3".
+	Name          string          `json:"Name"`
+	Actions       []*Action       `json:"Actions"`
+	ConditionKeys []*ConditionKey `json:"ConditionKeys"`
+	Resources     []*Resource     `json:"Resources"`
+	Version       string          `json:"Version,omitempty"`
+	// Partial is set when the service lists only some of its actions, as in
+	// the catalog embedded in this package. Wildcard action patterns of a
+	// partial service may match actions that are missing from it.
+	Partial bool `json:"Partial,omitempty"`
+
+	actions       map[string]*Action
+	resources     map[string]*Resource
+	conditionKeys map[string]*ConditionKey
+}
+
+// Action is an action of a service.
+type Action struct {
+	Name                string           `json:"Name"`
+	ActionConditionKeys []string         `json:"ActionConditionKeys,omitempty"`
+	Annotations         Annotations      `json:"Annotations"`
+	Resources           []ActionResource `json:"Resources,omitempty"`
+
+	service *Service
+}
+
+// Annotations holds the properties of an action.
+type Annotations struct {
+	Properties Properties `json:"Properties"`
+}
+
+// Properties classifies an action. The access level of an action is derived
+// from these flags.
+type Properties struct {
+	IsList                 bool `json:"IsList"`
+	IsPermissionManagement bool `json:"IsPermissionManagement"`
+	IsTaggingOnly          bool `json:"IsTaggingOnly"`
+	IsWrite                bool `json:"IsWrite"`
+}
+
+// ActionResource references a resource type an action can be scoped to.
+type ActionResource struct {
+	Name string `json:"Name"`
+}
+
+// Resource is a resource type of a service.
+type Resource struct {
+	Name          string   `json:"Name"`
+	ARNFormats    []string `json:"ARNFormats"`
+	ConditionKeys []string `json:"ConditionKeys,omitempty"`
+}
+
+// ConditionKey is a condition key supported by a service. Keys may contain
+// a variable, such as "aws:RequestTag/${TagKey}".
+type ConditionKey struct {
+	Name  string   `json:"Name"`
+	Types []string `json:"Types"`
+}
+
+// variableRegex matches variables in ARN formats and condition key names,
+// such as "${BucketName}".
+var variableRegex = regexp.MustCompile(`\$\{[^}]*\}`)
+
+// index builds the lookup tables of a service and links its actions to it.
+func (s *Service) index() {
+	s.actions = make(map[string]*Action, len(s.Actions))
+	for _, a := range s.Actions {
+		a.service = s
+		s.actions[strings.ToLower(a.Name)] = a
+	}
+	s.resources = make(map[string]*Resource, len(s.Resources))
+	for _, r := range s.Resources {
+		s.resources[r.Name] = r
+	}
+	s.conditionKeys = make(map[string]*ConditionKey, len(s.ConditionKeys))
+	for _, k := range s.ConditionKeys {
+		s.conditionKeys[strings.ToLower(k.Name)] = k
+	}
+}
+
+// Action returns the action with the given name, without the service prefix,
+// or nil if the service has no such action. Action names are
+// case-insensitive.
+func (s *Service) Action(name string) *Action {
+	return s.actions[strings.ToLower(name)]
+}
+
+// Resource returns the resource type with the given name, or nil if the
+// service has no such resource type.
+func (s *Service) Resource(name string) *Resource {
+	return s.resources[name]
+}
+
+// ConditionKey returns the condition key with the given name, or nil if the
+// service does not support it. Condition key names are case-insensitive, and
+// a name such as "aws:RequestTag/team" matches the key
+// "aws:RequestTag/${TagKey}".
+func (s *Service) ConditionKey(name string) *ConditionKey {
+	if k, ok := s.conditionKeys[strings.ToLower(name)]; ok {
+		return k
+	}
+	for _, k := range s.ConditionKeys {
+		if k.Matches(name) {
+			return k
+		}
+	}
+	return nil
+}
+
+// Service returns the prefix of the service the action belongs to.
+func (a *Action) Service() string {
+	if a.service == nil {
+		return ""
+	}
+	return a.service.Name
+}
+
+// String returns the full name of the action, such as "s3:GetObject".
+func (a *Action) String() string {
+	return a.Service() + ":" + a.Name
+}
+
+// AccessLevel returns the access level of the action, one of the AccessLevel
+// constants.
+func (a *Action) AccessLevel() string {
+	p := a.Annotations.Properties
+	switch {
+	case p.IsPermissionManagement:
+		return AccessLevelPermissionsManagement
+	case p.IsTaggingOnly:
+		return AccessLevelTagging
+	case p.IsWrite:
+		return AccessLevelWrite
+	case p.IsList:
+		return AccessLevelList
+	default:
+		return AccessLevelRead
+	}
+}
+
+// ResourceTypes returns the resource types the action can be scoped to. An
+// action with no resource types only supports the "*" resource.
+func (a *Action) ResourceTypes() []*Resource {
+	resp := []*Resource{}
+	if a.service == nil {
+		return resp
+	}
+	for _, r := range a.Resources {
+		if res := a.service.Resource(r.Name); res != nil {
+			resp = append(resp, res)
+		}
+	}
+	return resp
+}
+
+// SupportsConditionKey returns true if the condition key applies to the
+// action, either directly or through one of its resource types.
+func (a *Action) SupportsConditionKey(name string) bool {
+	for _, k := range a.ActionConditionKeys {
+		if keyMatches(k, name) {
+			return true
+		}
+	}
+	for _, r := range a.ResourceTypes() {
+		for _, k := range r.ConditionKeys {
+			if keyMatches(k, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Matches returns true if the ARN, or ARN pattern, matches one of the ARN
+// formats of the resource type.
+func (r *Resource) Matches(value string) bool {
+	for _, format := range r.ARNFormats {
+		if arn.Match(variableRegex.ReplaceAllString(format, "*"), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the condition key name matches the key, either
+// exactly or by substituting the key's variable.
+func (k *ConditionKey) Matches(name string) bool {
+	return keyMatches(k.Name, name)
+}
+
+func keyMatches(key, name string) bool {
+	if strings.EqualFold(key, name) {
+		return true
+	}
+	if !variableRegex.MatchString(key) {
+		return false
+	}
+	return wildcard.MatchFold(variableRegex.ReplaceAllString(key, "*"), name)
+}
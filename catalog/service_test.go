@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestActionResourceTypes(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{in: "s3:GetObject", want: []string{"object"}},
+		{in: "s3:ListAllMyBuckets", want: []string{}},
+		{in: "ec2:AttachVolume", want: []string{"instance", "volume"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got := []string{}
+			for _, r := range Default().Action(tc.in).ResourceTypes() {
+				got = append(got, r.Name)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("unexpected resource types: %s", diff)
+			}
+		})
+	}
+}
+
+func TestActionSupportsConditionKey(t *testing.T) {
+	cases := []struct {
+		action string
+		key    string
+		want   bool
+	}{
+		{action: "s3:ListBucket", key: "s3:prefix", want: true},
+		{action: "s3:GetObject", key: "s3:prefix", want: false},
+		{action: "s3:GetObject", key: "s3:ExistingObjectTag/classification", want: true},
+		{action: "iam:PassRole", key: "iam:PassedToService", want: true},
+		{action: "iam:GetRole", key: "iam:ResourceTag/team", want: true},
+		{action: "iam:GetRole", key: "iam:PassedToService", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.action+"/"+tc.key, func(t *testing.T) {
+			if got := Default().Action(tc.action).SupportsConditionKey(tc.key); got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResourceMatches(t *testing.T) {
+	cases := []struct {
+		service  string
+		resource string
+		in       string
+		want     bool
+	}{
+		{service: "s3", resource: "object", in: "arn:aws:s3:::examplebucket/key.txt", want: true},
+		{service: "s3", resource: "object", in: "arn:aws:s3:::examplebucket/*", want: true},
+		{service: "iam", resource: "role", in: "arn:aws:iam::123456789012:role/admin", want: true},
+		{service: "iam", resource: "role", in: "arn:aws:iam::123456789012:user/admin", want: false},
+		{service: "lambda", resource: "function", in: "arn:aws-cn:lambda:cn-north-1:123456789012:function:f", want: true},
+		{service: "sqs", resource: "queue", in: "arn:aws:sns:us-east-1:123456789012:topic", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			r := Default().Service(tc.service).Resource(tc.resource)
+			if got := r.Matches(tc.in); got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Package cfn reads the IAM policy documents out of [CloudFormation templates].
+
+Templates may be written in JSON or YAML, including the YAML short forms of
+intrinsic functions such as !Sub and !GetAtt. Policies are decoded with
+[policy.AllowIntrinsics] and [policy.PreserveKeyOrder], so intrinsic functions in Resource, Principal and
+Condition values are kept, and can be resolved with
+[policy.Policy.ResolveIntrinsics]:
+
+	docs, err := cfn.LoadFile("template.yaml")
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		fmt.Println(doc.LogicalID, doc.Path)
+	}
+	// BucketPolicy /Resources/BucketPolicy/Properties/PolicyDocument
+
+[CloudFormation templates]: https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/template-anatomy.html
+*/
+package cfn
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+const (
+	ErrorNotAnObject = "expected a JSON object"
+)
+
+// Document is a policy document found in a template.
+type Document struct {
+	// LogicalID is the logical ID of the resource that holds the policy.
+	LogicalID string
+	// Type is the resource type, such as "AWS::IAM::Role".
+	Type string
+	// Path is the JSON pointer (RFC 6901) of the policy in the template, such
+	// as "/Resources/MyRole/Properties/Policies/0/PolicyDocument".
+	Path string
+	// Policy is the decoded policy.
+	Policy *policy.Policy
+}
+
+// policyProperties lists the properties of each resource type that hold a
+// policy document.
+var policyProperties = map[string][]string{
+	"AWS::IAM::Policy":        {"PolicyDocument"},
+	"AWS::IAM::ManagedPolicy": {"PolicyDocument"},
+	"AWS::IAM::RolePolicy":    {"PolicyDocument"},
+	"AWS::IAM::UserPolicy":    {"PolicyDocument"},
+	"AWS::IAM::GroupPolicy":   {"PolicyDocument"},
+	"AWS::IAM::Role":          {"AssumeRolePolicyDocument"},
+	"AWS::S3::BucketPolicy":   {"PolicyDocument"},
+	"AWS::SQS::QueuePolicy":   {"PolicyDocument"},
+	"AWS::SNS::TopicPolicy":   {"PolicyDocument"},
+	"AWS::KMS::Key":           {"KeyPolicy"},
+}
+
+// inlinePolicyTypes are the resource types with a Policies property holding
+// a list of inline policies.
+var inlinePolicyTypes = map[string]bool{
+	"AWS::IAM::Role":  true,
+	"AWS::IAM::User":  true,
+	"AWS::IAM::Group": true,
+}
+
+// LoadFile reads the policies in a template file.
+func LoadFile(name string) ([]*Document, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data)
+}
+
+// Load reads the policies in a JSON or YAML template. Documents are returned
+// in the order of the template's resources. An error is returned if a policy
+// cannot be decoded.
+func Load(data []byte) ([]*Document, error) {
+	if !isJSON(data) {
+		var err error
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	template, err := objectMembers(data)
+	if err != nil {
+		return nil, err
+	}
+	var docs []*Document
+	for _, section := range template {
+		if section.key != "Resources" {
+			continue
+		}
+		resources, err := objectMembers(section.value)
+		if err != nil {
+			return nil, fmt.Errorf("/Resources: %w", err)
+		}
+		for _, r := range resources {
+			found, err := resourcePolicies(r.key, r.value)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, found...)
+		}
+	}
+	return docs, nil
+}
+
+// isJSON returns true if the document is a JSON object.
+func isJSON(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// resourcePolicies returns the policies of a resource.
+func resourcePolicies(logicalID string, data json.RawMessage) ([]*Document, error) {
+	base := jsonpointer.Append("/Resources", logicalID)
+	resource := struct {
+		Type       string
+		Properties map[string]json.RawMessage
+	}{}
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, fmt.Errorf("%s: %w", base, err)
+	}
+	var docs []*Document
+	add := func(path string, raw json.RawMessage) error {
+		p, err := policy.Unmarshal(raw, policy.AllowIntrinsics(), policy.PreserveKeyOrder())
+		if err != nil {
+			// Positions in the policy do not match the template, so only
+			// the pointer is kept.
+			var de *policy.DecodeError
+			if errors.As(err, &de) {
+				return fmt.Errorf("%s%s: %w", path, de.Pointer, de.Err)
+			}
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		docs = append(docs, &Document{LogicalID: logicalID, Type: resource.Type, Path: path, Policy: p})
+		return nil
+	}
+
+	for _, property := range policyProperties[resource.Type] {
+		raw, ok := resource.Properties[property]
+		if !ok {
+			continue
+		}
+		if err := add(jsonpointer.Append(base, "Properties", property), raw); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := resource.Properties["Policies"]; ok && inlinePolicyTypes[resource.Type] {
+		inline := []struct {
+			PolicyDocument json.RawMessage
+		}{}
+		if err := json.Unmarshal(raw, &inline); err != nil {
+			return nil, fmt.Errorf("%s: %w", jsonpointer.Append(base, "Properties", "Policies"), err)
+		}
+		for i, p := range inline {
+			if p.PolicyDocument == nil {
+				continue
+			}
+			path := jsonpointer.Append(base, "Properties", "Policies", strconv.Itoa(i), "PolicyDocument")
+			if err := add(path, p.PolicyDocument); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return docs, nil
+}
+
+// member is a member of a JSON object.
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// objectMembers returns the members of a JSON object in document order.
+func objectMembers(data []byte) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New(ErrorNotAnObject)
+	}
+	var members []member
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, member{key: key, value: value})
+	}
+	return members, nil
+}
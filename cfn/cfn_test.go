@@ -0,0 +1,91 @@
+package cfn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		want []string
+	}{
+		{
+			name: "YAML",
+			file: "testdata/template.yaml",
+			want: []string{
+				`BucketPolicy AWS::S3::BucketPolicy /Resources/BucketPolicy/Properties/PolicyDocument {"Version":"2012-10-17","Statement":[{"Sid":"DenyInsecureTransport","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":[{"Fn::GetAtt":["Bucket","Arn"]},{"Fn::Sub":"${Bucket.Arn}/*"}],"Condition":{"Bool":{"aws:SecureTransport":false}}}]}`,
+				`Role AWS::IAM::Role /Resources/Role/Properties/AssumeRolePolicyDocument {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+				`Role AWS::IAM::Role /Resources/Role/Properties/Policies/0/PolicyDocument {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":{"Fn::Join":["",[{"Fn::GetAtt":["Bucket","Arn"]},"/*"]]}}]}`,
+				`Key AWS::KMS::Key /Resources/Key/Properties/KeyPolicy {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":{"Fn::Sub":"arn:${AWS::Partition}:iam::${AWS::AccountId}:root"}},"Action":"kms:*","Resource":"*"}]}`,
+			},
+		},
+		{
+			name: "JSON",
+			file: "testdata/template.json",
+			want: []string{
+				`TopicPolicy AWS::SNS::TopicPolicy /Resources/TopicPolicy/Properties/PolicyDocument {"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":"sns:Publish","Resource":{"Ref":"Topic"}}}`,
+				`ManagedPolicy AWS::IAM::ManagedPolicy /Resources/ManagedPolicy/Properties/PolicyDocument {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"sqs:SendMessage","Resource":{"Fn::GetAtt":["Queue","Arn"]}}]}`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			docs, err := LoadFile(tc.file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(docs) != len(tc.want) {
+				t.Fatalf("got %d documents, want %d", len(docs), len(tc.want))
+			}
+			for i, doc := range docs {
+				b, err := json.Marshal(doc.Policy)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got := doc.LogicalID + " " + doc.Type + " " + doc.Path + " " + string(b)
+				if got != tc.want[i] {
+					t.Errorf("got '%s', want '%s'", got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "NotAnObject",
+			in:   `["a"]`,
+			want: ErrorNotAnObject,
+		},
+		{
+			name: "Empty",
+			in:   ``,
+			want: ErrorEmptyTemplate,
+		},
+		{
+			name: "InvalidPolicy",
+			in:   `{"Resources": {"P": {"Type": "AWS::IAM::Policy", "Properties": {"PolicyDocument": {"Statement": [{"Effect": "Allow", "Actions": "s3:*"}]}}}}}`,
+			want: `/Resources/P/Properties/PolicyDocument/Statement/0/Actions: unknown field "Actions"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load([]byte(tc.in))
+			if err == nil {
+				t.Fatalf("expected error '%s', got none", tc.want)
+			}
+			if err.Error() != tc.want {
+				t.Errorf("got '%s', want '%s'", err.Error(), tc.want)
+			}
+		})
+	}
+}
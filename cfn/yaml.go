@@ -0,0 +1,72 @@
+package cfn
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/micahhausler/aws-iam-policy/internal/yamljson"
+)
+
+const (
+	ErrorEmptyTemplate = "template is empty"
+)
+
+// yamlToJSON converts a YAML template to JSON, expanding the short forms of
+// intrinsic functions.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, errors.New(ErrorEmptyTemplate)
+	}
+	expandShortForms(doc.Content[0])
+	return yamljson.NodeToJSON(doc.Content[0])
+}
+
+// expandShortForms rewrites nodes tagged with the short form of an intrinsic
+// function, such as "!Sub x", as their full form, {"Fn::Sub": "x"}.
+func expandShortForms(n *yaml.Node) {
+	for _, c := range n.Content {
+		expandShortForms(c)
+	}
+	tag := n.Tag
+	if !strings.HasPrefix(tag, "!") || strings.HasPrefix(tag, "!!") {
+		return
+	}
+	name := "Fn::" + tag[1:]
+	switch tag {
+	case "!Ref":
+		name = "Ref"
+	case "!Condition":
+		name = "Condition"
+	}
+
+	value := *n
+	value.Tag = ""
+	if value.Kind == yaml.ScalarNode {
+		value.Tag = "!!str"
+	}
+	if tag == "!GetAtt" && value.Kind == yaml.ScalarNode {
+		// !GetAtt Resource.Attribute is short for ["Resource", "Attribute"].
+		if i := strings.Index(value.Value, "."); i > 0 {
+			value = yaml.Node{
+				Kind: yaml.SequenceNode,
+				Content: []*yaml.Node{
+					{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Value[:i]},
+					{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Value[i+1:]},
+				},
+			}
+		}
+	}
+	*n = yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+			&value,
+		},
+	}
+}
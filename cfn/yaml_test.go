@@ -0,0 +1,61 @@
+package cfn
+
+import (
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "Ref",
+			in:   `a: !Ref Bucket`,
+			want: `{"a":{"Ref":"Bucket"}}`,
+		},
+		{
+			name: "GetAttScalar",
+			in:   `a: !GetAtt Role.Arn`,
+			want: `{"a":{"Fn::GetAtt":["Role","Arn"]}}`,
+		},
+		{
+			name: "GetAttSequence",
+			in:   `a: !GetAtt [Role, Arn]`,
+			want: `{"a":{"Fn::GetAtt":["Role","Arn"]}}`,
+		},
+		{
+			name: "Nested",
+			in:   `a: !Join [":", [!Ref "AWS::Region", 123]]`,
+			want: `{"a":{"Fn::Join":[":",[{"Ref":"AWS::Region"},123]]}}`,
+		},
+		{
+			name: "SubMapping",
+			in:   "a: !Sub\n  - ${A}\n  - A: !Ref B",
+			want: `{"a":{"Fn::Sub":["${A}",{"A":{"Ref":"B"}}]}}`,
+		},
+		{
+			name: "Condition",
+			in:   `a: !If [!Condition IsProd, "true", !Ref "AWS::NoValue"]`,
+			want: `{"a":{"Fn::If":[{"Condition":"IsProd"},"true",{"Ref":"AWS::NoValue"}]}}`,
+		},
+		{
+			name: "LongForm",
+			in:   `a: {"Fn::Sub": "x"}`,
+			want: `{"a":{"Fn::Sub":"x"}}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := yamlToJSON([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(got), tc.want)
+			}
+		})
+	}
+}
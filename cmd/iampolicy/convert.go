@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/micahhausler/aws-iam-policy/internal/yamljson"
+	"github.com/micahhausler/aws-iam-policy/terraform"
+)
+
+// Conversion targets.
+const (
+	targetJSON = "json"
+	targetYAML = "yaml"
+	targetHCL  = "hcl"
+)
+
+// defaultHCLName is the name of the data source written for policies that
+// were not read from HCL.
+const defaultHCLName = "policy"
+
+func runConvert(e *env, args []string) int {
+	fs := newFlagSet(e, "convert", "[file]")
+	to := fs.String("to", targetJSON, "output format of the policy, json, yaml or hcl")
+	name := fs.String("name", "", "name of the data source written by -to hcl, defaults to the name read from HCL input or \""+defaultHCLName+"\"")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 1 {
+		return errorf(e, "convert takes at most one policy")
+	}
+
+	in, err := readInput(e, inputNames(fs.Args())[0])
+	if err != nil {
+		return errorf(e, "%v", err)
+	}
+	out, err := marshalPolicy(in.policy)
+	if err != nil {
+		return errorf(e, "%s: %v", in.name, err)
+	}
+	switch *to {
+	case targetJSON:
+	case targetYAML:
+		out, err = yamljson.FromJSON(out)
+		if err != nil {
+			return errorf(e, "%s: %v", in.name, err)
+		}
+	case targetHCL:
+		dataName := *name
+		switch {
+		case dataName != "":
+		case in.hcl:
+			dataName = in.hclName
+		default:
+			dataName = defaultHCLName
+		}
+		out, err = terraform.FormatHCL(dataName, in.policy)
+		if err != nil {
+			return errorf(e, "%s: %v", in.name, err)
+		}
+	default:
+		return errorf(e, "unknown target %q, must be %q, %q or %q", *to, targetJSON, targetYAML, targetHCL)
+	}
+	e.stdout.Write(out)
+	return exitOK
+}
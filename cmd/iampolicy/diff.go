@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/catalog"
+	"github.com/micahhausler/aws-iam-policy/diff"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// diffResult is the result of a comparison in JSON output.
+type diffResult struct {
+	Equal bool
+	Diff  []string
+}
+
+// semanticDiffResult is the result of a semantic comparison in JSON output.
+type semanticDiffResult struct {
+	Equal     bool
+	Broadened bool
+	Changes   []*diff.Change
+}
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+func runDiff(e *env, args []string) int {
+	fs := newFlagSet(e, "diff", "old new")
+	format := fs.String("format", formatText, "output format, text or json")
+	semantic := fs.Bool("semantic", false, "compare the access granted by the policies instead of their text")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if err := checkFormat(*format); err != nil {
+		return errorf(e, "%v", err)
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	policies := make([]*policy.Policy, 2)
+	lines := make([][]string, 2)
+	for i, name := range fs.Args() {
+		in, err := readInput(e, name)
+		if err != nil {
+			return errorf(e, "%v", err)
+		}
+		out, err := marshalPolicy(in.policy)
+		if err != nil {
+			return errorf(e, "%s: %v", name, err)
+		}
+		policies[i] = in.policy
+		lines[i] = strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	}
+	if *semantic {
+		return semanticDiff(e, policies[0], policies[1], *format)
+	}
+	hunks := unifiedDiff(lines[0], lines[1], diffContext)
+
+	if *format == formatJSON {
+		if err := writeJSON(e.stdout, diffResult{Equal: len(hunks) == 0, Diff: hunks}); err != nil {
+			return errorf(e, "%v", err)
+		}
+	} else if len(hunks) > 0 {
+		fmt.Fprintf(e.stdout, "--- %s\n+++ %s\n", fs.Arg(0), fs.Arg(1))
+		for _, line := range hunks {
+			fmt.Fprintln(e.stdout, line)
+		}
+	}
+	if len(hunks) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// semanticDiff reports the access added and removed between two policies,
+// expanding action wildcards with the default catalog.
+func semanticDiff(e *env, old, new *policy.Policy, format string) int {
+	result := diff.Compare(old, new, catalog.Default())
+	if format == formatJSON {
+		err := writeJSON(e.stdout, semanticDiffResult{
+			Equal:     result.Equal(),
+			Broadened: result.Broadened(),
+			Changes:   result.Changes,
+		})
+		if err != nil {
+			return errorf(e, "%v", err)
+		}
+	} else {
+		for _, c := range result.Changes {
+			fmt.Fprintln(e.stdout, c)
+		}
+	}
+	if !result.Equal() {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// edit is a line of a diff: ' ' for an unchanged line, '-' for a removed line
+// and '+' for an added line.
+type edit struct {
+	op   byte
+	line string
+	// a and b are the line numbers of the line in each input, starting at 0.
+	a, b int
+}
+
+// lineDiff returns the edits that turn a into b, using the longest common
+// subsequence of lines.
+func lineDiff(a, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	edits := []edit{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{op: ' ', line: a[i], a: i, b: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{op: '-', line: a[i], a: i, b: j})
+			i++
+		default:
+			edits = append(edits, edit{op: '+', line: b[j], a: i, b: j})
+			j++
+		}
+	}
+	return edits
+}
+
+// unifiedDiff returns the lines of a unified diff of a and b with the given
+// number of context lines, or nil if a and b are equal.
+func unifiedDiff(a, b []string, context int) []string {
+	edits := lineDiff(a, b)
+	var resp []string
+	for start := 0; start < len(edits); {
+		// Find the next change.
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		// Extend the hunk until there are more than 2*context unchanged
+		// lines in a row.
+		end := start
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		first := start - context
+		if first < 0 {
+			first = 0
+		}
+		last := end + context
+		if last > len(edits) {
+			last = len(edits)
+		}
+		hunk := edits[first:last]
+		aCount, bCount := 0, 0
+		for _, e := range hunk {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		resp = append(resp, fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk[0].a+1, aCount, hunk[0].b+1, bCount))
+		for _, e := range hunk {
+			resp = append(resp, string(e.op)+e.line)
+		}
+		start = last
+	}
+	return resp
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		a    string
+		b    string
+		want []string
+	}{
+		{
+			name: "Equal",
+			a:    "a b c",
+			b:    "a b c",
+		},
+		{
+			name: "Change",
+			a:    "a b c d e f g h i",
+			b:    "a b c d X f g h i",
+			want: []string{"@@ -2,7 +2,7 @@", " b", " c", " d", "-e", "+X", " f", " g", " h"},
+		},
+		{
+			name: "SeparateHunks",
+			a:    "1 2 3 4 5 6 7 8 9 10 11 12",
+			b:    "0 1 2 3 4 5 6 7 8 9 10 11",
+			want: []string{"@@ -1,3 +1,4 @@", "+0", " 1", " 2", " 3", "@@ -9,4 +10,3 @@", " 9", " 10", " 11", "-12"},
+		},
+		{
+			name: "MergedHunks",
+			a:    "1 2 3 4 5",
+			b:    "X 2 3 4 Y",
+			want: []string{"@@ -1,5 +1,5 @@", "-1", "+X", " 2", " 3", " 4", "-5", "+Y"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := unifiedDiff(strings.Fields(tc.a), strings.Fields(tc.b), 3)
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("unexpected diff: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/condition"
+	"github.com/micahhausler/aws-iam-policy/eval"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// contextFlag collects repeated key=value condition context flags.
+type contextFlag condition.Context
+
+func (c contextFlag) String() string {
+	pairs := []string{}
+	for key, values := range c {
+		for _, value := range values {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (c contextFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("context must be key=value, got %q", s)
+	}
+	c[key] = append(c[key], value)
+	return nil
+}
+
+// evalResult is the result of an evaluation in JSON output.
+type evalResult struct {
+	Decision string
+	Matched  []eval.StatementMatch
+}
+
+func runEval(e *env, args []string) int {
+	fs := newFlagSet(e, "eval", "[file]")
+	format := fs.String("format", formatText, "output format, text or json")
+	action := fs.String("action", "", "action of the request, such as s3:GetObject (required)")
+	resource := fs.String("resource", "", "ARN of the resource of the request")
+	principalID := fs.String("principal", "", "principal making the request, such as a role ARN")
+	principalKind := fs.String("principal-type", policy.PrincipalKindAWS, "type of the principal: AWS, CanonicalUser, Federated or Service")
+	ctx := contextFlag{}
+	fs.Var(ctx, "context", "condition key `key=value` of the request; repeat for multiple keys or values")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if err := checkFormat(*format); err != nil {
+		return errorf(e, "%v", err)
+	}
+	if *action == "" {
+		fs.Usage()
+		return exitError
+	}
+	if fs.NArg() > 1 {
+		return errorf(e, "eval takes at most one policy")
+	}
+
+	in, err := readInput(e, inputNames(fs.Args())[0])
+	if err != nil {
+		return errorf(e, "%v", err)
+	}
+	req := &eval.Request{
+		Action:   *action,
+		Resource: *resource,
+		Context:  condition.Context(ctx),
+	}
+	if *principalID != "" {
+		req.Principal = &eval.Principal{Kind: *principalKind, ID: *principalID}
+	}
+	result, err := eval.Evaluate(in.policy, req)
+	if err != nil {
+		return errorf(e, "%s: %v", in.name, err)
+	}
+
+	if *format == formatJSON {
+		out := evalResult{Decision: result.Decision.String(), Matched: result.Matched}
+		if out.Matched == nil {
+			out.Matched = []eval.StatementMatch{}
+		}
+		if err := writeJSON(e.stdout, out); err != nil {
+			return errorf(e, "%v", err)
+		}
+	} else {
+		fmt.Fprintln(e.stdout, result.Decision)
+		for _, m := range result.Matched {
+			if m.Sid != "" {
+				fmt.Fprintf(e.stdout, "  statement %d (%s): %s\n", m.Index, m.Sid, m.Effect)
+			} else {
+				fmt.Fprintf(e.stdout, "  statement %d: %s\n", m.Index, m.Effect)
+			}
+		}
+	}
+	if result.Decision != eval.Allow {
+		return exitFailure
+	}
+	return exitOK
+}
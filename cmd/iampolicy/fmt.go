@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/micahhausler/aws-iam-policy/internal/yamljson"
+	"github.com/micahhausler/aws-iam-policy/terraform"
+)
+
+func runFmt(e *env, args []string) int {
+	fs := newFlagSet(e, "fmt", "[file ...]")
+	write := fs.Bool("w", false, "write the result to the file instead of standard output")
+	check := fs.Bool("check", false, "list files that are not formatted and exit with status 1 if any are found")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	code := exitOK
+	for _, name := range inputNames(fs.Args()) {
+		in, err := readInput(e, name)
+		if err != nil {
+			return errorf(e, "%v", err)
+		}
+		out, err := formatInput(in)
+		if err != nil {
+			return errorf(e, "%s: %v", name, err)
+		}
+		switch {
+		case *check:
+			if !bytes.Equal(in.data, out) {
+				fmt.Fprintln(e.stdout, name)
+				code = exitFailure
+			}
+		case *write && name != stdinName:
+			if bytes.Equal(in.data, out) {
+				continue
+			}
+			info, err := os.Stat(name)
+			if err != nil {
+				return errorf(e, "%v", err)
+			}
+			if err := os.WriteFile(name, out, info.Mode().Perm()); err != nil {
+				return errorf(e, "%v", err)
+			}
+		default:
+			e.stdout.Write(out)
+		}
+	}
+	return code
+}
+
+// formatInput returns the canonical form of a policy, in the format it was
+// read in.
+func formatInput(in *input) ([]byte, error) {
+	if in.hcl {
+		return terraform.FormatHCL(in.hclName, in.policy)
+	}
+	out, err := marshalPolicy(in.policy)
+	if err != nil {
+		return nil, err
+	}
+	if in.yaml {
+		return yamljson.FromJSON(out)
+	}
+	return out, nil
+}
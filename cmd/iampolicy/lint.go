@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/lint"
+)
+
+// lintResult is a lint finding in JSON output.
+type lintResult struct {
+	File string
+	*lint.Finding
+}
+
+func runLint(e *env, args []string) int {
+	fs := newFlagSet(e, "lint", "[file ...]")
+	format := fs.String("format", formatText, "output format, text or json")
+	failOn := fs.String("fail-on", string(lint.SeverityWarning), "exit with status 1 if a finding is at least this severe: ERROR, SECURITY_WARNING, WARNING or SUGGESTION")
+	checkIDs := fs.String("checks", "", "comma-separated IDs of the checks to run (default all)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if err := checkFormat(*format); err != nil {
+		return errorf(e, "%v", err)
+	}
+	threshold := lint.Severity(strings.ToUpper(*failOn))
+	switch threshold {
+	case lint.SeverityError, lint.SeveritySecurityWarning, lint.SeverityWarning, lint.SeveritySuggestion:
+	default:
+		return errorf(e, "unknown severity %q", *failOn)
+	}
+	checks := lint.Checks()
+	if *checkIDs != "" {
+		checks = nil
+		for _, id := range strings.Split(*checkIDs, ",") {
+			c, ok := lint.Lookup(strings.TrimSpace(id))
+			if !ok {
+				return errorf(e, "unknown check %q", id)
+			}
+			checks = append(checks, c)
+		}
+	}
+
+	results := []lintResult{}
+	code := exitOK
+	for _, name := range inputNames(fs.Args()) {
+		in, err := readInput(e, name)
+		if err != nil {
+			return errorf(e, "%v", err)
+		}
+		for _, f := range lint.RunChecks(in.policy, checks...) {
+			results = append(results, lintResult{File: name, Finding: f})
+			if f.Severity.AtLeast(threshold) {
+				code = exitFailure
+			}
+		}
+	}
+
+	if *format == formatJSON {
+		if err := writeJSON(e.stdout, results); err != nil {
+			return errorf(e, "%v", err)
+		}
+	} else {
+		for _, r := range results {
+			fmt.Fprintf(e.stdout, "%s:%s: %s %s: %s\n", r.File, r.Path, r.Severity, r.CheckID, r.Message)
+		}
+	}
+	return code
+}
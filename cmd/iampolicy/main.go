@@ -0,0 +1,267 @@
+/*
+Command iampolicy formats, validates, lints, evaluates, compares and converts
+AWS IAM policy documents.
+
+Usage:
+
+	iampolicy <command> [flags] [file ...]
+
+The commands are:
+
+	fmt       reformat policies
+	validate  check policies for structural errors
+	lint      check policies for likely mistakes
+	eval      evaluate a request against a policy
+	diff      compare two policies
+	convert   convert a policy between JSON, YAML and HCL
+
+Commands read policies from the named files, or from standard input when no
+file or "-" is given. Policies may be written in JSON, YAML, or HCL as a
+single Terraform aws_iam_policy_document data source with literal values.
+
+The exit status is 0 on success, 1 when a check fails (a policy is invalid or
+not formatted, a finding is reported, a request is denied, or policies
+differ), and 2 for usage errors and policies that cannot be read.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/yamljson"
+	"github.com/micahhausler/aws-iam-policy/policy"
+	"github.com/micahhausler/aws-iam-policy/terraform"
+)
+
+// Exit codes.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitError   = 2
+)
+
+// Output formats.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+const stdinName = "-"
+
+// command is a subcommand of the tool.
+type command struct {
+	name    string
+	summary string
+	run     func(env *env, args []string) int
+}
+
+// env holds the standard streams, so commands can be tested.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "fmt", summary: "reformat policies", run: runFmt},
+		{name: "validate", summary: "check policies for structural errors", run: runValidate},
+		{name: "lint", summary: "check policies for likely mistakes", run: runLint},
+		{name: "eval", summary: "evaluate a request against a policy", run: runEval},
+		{name: "diff", summary: "compare two policies", run: runDiff},
+		{name: "convert", summary: "convert a policy between JSON, YAML and HCL", run: runConvert},
+	}
+}
+
+func main() {
+	os.Exit(run(&env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}, os.Args[1:]))
+}
+
+func run(e *env, args []string) int {
+	if len(args) == 0 {
+		usage(e.stderr)
+		return exitError
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage(e.stdout)
+		return exitOK
+	}
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(e, args[1:])
+		}
+	}
+	fmt.Fprintf(e.stderr, "iampolicy: unknown command %q\n", name)
+	usage(e.stderr)
+	return exitError
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: iampolicy <command> [flags] [file ...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "iampolicy <command> -h" for the flags of a command.`)
+}
+
+// newFlagSet returns a flag set for a command that reports errors to stderr.
+func newFlagSet(e *env, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: iampolicy %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a command. It returns false and the exit code
+// if the command should exit.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
+		}
+		return exitError, false
+	}
+	return exitOK, true
+}
+
+// checkFormat returns an error if the output format is unknown.
+func checkFormat(format string) error {
+	if format != formatText && format != formatJSON {
+		return fmt.Errorf("unknown format %q, must be %q or %q", format, formatText, formatJSON)
+	}
+	return nil
+}
+
+// input is a policy read from a file or standard input.
+type input struct {
+	name string
+	data []byte
+	yaml bool
+	hcl  bool
+	// hclName is the name of the data source of HCL input.
+	hclName string
+	policy  *policy.Policy
+}
+
+// inputNames returns the files named on the command line, or standard input
+// if none are named.
+func inputNames(args []string) []string {
+	if len(args) == 0 {
+		return []string{stdinName}
+	}
+	return args
+}
+
+// readInput reads and decodes a policy.
+func readInput(e *env, name string) (*input, error) {
+	var data []byte
+	var err error
+	if name == stdinName {
+		data, err = io.ReadAll(e.stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isHCL(data) {
+		return readHCL(name, data)
+	}
+	in := &input{name: name, data: data, yaml: isYAML(data)}
+	jsonData := data
+	if in.yaml {
+		jsonData, err = yamljson.ToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	in.policy, err = policy.Unmarshal(jsonData)
+	if err != nil {
+		return nil, decodeError(name, in.yaml, err)
+	}
+	return in, nil
+}
+
+// readHCL decodes a policy from an aws_iam_policy_document data source.
+func readHCL(name string, data []byte) (*input, error) {
+	docs, err := terraform.ParseHCL(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(docs) != 1 {
+		return nil, fmt.Errorf("%s: found %d policy documents, want 1", name, len(docs))
+	}
+	return &input{name: name, data: data, hcl: true, hclName: docs[0].Name, policy: docs[0].Policy}, nil
+}
+
+// decodeError prefixes a decoding error with the file name and, for JSON
+// input, the line and column where decoding failed. Positions in YAML input
+// are lost in conversion, so only the JSON pointer is reported.
+func decodeError(name string, yaml bool, err error) error {
+	de, ok := err.(*policy.DecodeError)
+	if !ok {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	location := name
+	if !yaml {
+		location = fmt.Sprintf("%s:%d:%d", name, de.Line, de.Column)
+	}
+	if de.Pointer != "" {
+		location += ":" + de.Pointer
+	}
+	return fmt.Errorf("%s: %w", location, de.Err)
+}
+
+// hclPattern matches a document starting with a data source, after any
+// comment lines.
+var hclPattern = regexp.MustCompile(`\A(\s*(#|//).*\n)*\s*data\s+"`)
+
+// isHCL returns true if the document is a Terraform data source.
+func isHCL(data []byte) bool {
+	return hclPattern.Match(data)
+}
+
+// isYAML returns true if the document is not a JSON object.
+func isYAML(data []byte) bool {
+	trimmed := strings.TrimSpace(string(data))
+	return !strings.HasPrefix(trimmed, "{")
+}
+
+// marshalPolicy encodes a policy as JSON indented with two spaces.
+func marshalPolicy(p *policy.Policy) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes a value as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// errorf reports an error and returns the error exit code.
+func errorf(e *env, format string, args ...interface{}) int {
+	fmt.Fprintf(e.stderr, "iampolicy: "+format+"\n", args...)
+	return exitError
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:     "NoCommand",
+			wantCode: exitError,
+		},
+		{
+			name:       "UnknownCommand",
+			args:       []string{"bogus"},
+			wantCode:   exitError,
+			wantStderr: `iampolicy: unknown command "bogus"`,
+		},
+		{
+			name:       "Help",
+			args:       []string{"help"},
+			wantStdout: "Usage: iampolicy <command> [flags] [file ...]",
+		},
+		{
+			name:       "FmtStdin",
+			args:       []string{"fmt"},
+			stdin:      `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"s3:*","Resource":"<b>"}}`,
+			wantStdout: "{\n  \"Statement\": {\n    \"Action\": \"s3:*\",\n    \"Effect\": \"Allow\",\n    \"Resource\": \"<b>\"\n  },\n  \"Version\": \"2012-10-17\"\n}\n",
+		},
+		{
+			name: "FmtCheckFormatted",
+			args: []string{"fmt", "-check", "testdata/passrole.json", "testdata/bucket.yaml"},
+		},
+		{
+			name:       "FmtCheckUnformatted",
+			args:       []string{"fmt", "-check", "testdata/invalid.json"},
+			wantCode:   exitFailure,
+			wantStdout: "testdata/invalid.json\n",
+		},
+		{
+			name:       "FmtMalformed",
+			args:       []string{"fmt", "testdata/malformed.json"},
+			wantCode:   exitError,
+			wantStderr: "iampolicy: testdata/malformed.json:1:15: unexpected end of JSON input",
+		},
+		{
+			name:       "FmtUnknownField",
+			args:       []string{"fmt"},
+			stdin:      "{\"Version\": \"2012-10-17\",\n \"Statement\": [{\"Effect\": \"Allow\", \"Actions\": \"s3:*\"}]}",
+			wantCode:   exitError,
+			wantStderr: "iampolicy: -:2:36:/Statement/0/Actions: unknown field \"Actions\"",
+		},
+		{
+			name:       "FmtMissingFile",
+			args:       []string{"fmt", "testdata/missing.json"},
+			wantCode:   exitError,
+			wantStderr: "iampolicy: open testdata/missing.json: no such file or directory",
+		},
+		{
+			name: "ValidateValid",
+			args: []string{"validate", "testdata/passrole.json", "testdata/bucket.yaml"},
+		},
+		{
+			name:       "ValidateDuplicateKey",
+			args:       []string{"validate"},
+			stdin:      `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Action": "s3:GetObject"}}`,
+			wantCode:   exitFailure,
+			wantStdout: "-:/Statement/Action: duplicate key \"Action\" at line 1, column 95 (DuplicateKey)\n",
+		},
+		{
+			name:     "ValidateInvalid",
+			args:     []string{"validate", "testdata/invalid.json"},
+			wantCode: exitFailure,
+			wantStdout: "testdata/invalid.json:/Version: Version is required (MissingVersion)\n" +
+				"testdata/invalid.json:/Statement/Effect: Effect must be \"Allow\" or \"Deny\", got \"allow\" (InvalidEffect)\n" +
+				"testdata/invalid.json:/Statement/Resource: one of Resource or NotResource is required in identity-based policies (MissingResource)\n",
+		},
+		{
+			name:     "ValidateJSON",
+			args:     []string{"validate", "-format", "json", "testdata/invalid.json"},
+			wantCode: exitFailure,
+			wantStdout: `{
+    "File": "testdata/invalid.json",
+    "Path": "/Version",
+    "Code": "MissingVersion",
+    "Message": "Version is required"
+  },`,
+		},
+		{
+			name:       "ValidateUnknownFormat",
+			args:       []string{"validate", "-format", "xml", "testdata/invalid.json"},
+			wantCode:   exitError,
+			wantStderr: `iampolicy: unknown format "xml", must be "text" or "json"`,
+		},
+		{
+			name:       "Lint",
+			args:       []string{"lint", "testdata/passrole.json", "testdata/bucket.yaml"},
+			wantCode:   exitFailure,
+			wantStdout: "testdata/passrole.json:/Statement/0/Resource: SECURITY_WARNING PASS_ROLE_WITH_STAR_IN_RESOURCE: iam:PassRole is allowed on all resources; limit the Resource to the ARNs of the roles that can be passed\n",
+		},
+		{
+			name:     "LintFailOnError",
+			args:     []string{"lint", "-fail-on", "error", "-format", "json", "testdata/passrole.json"},
+			wantCode: exitOK,
+			wantStdout: `[
+  {
+    "File": "testdata/passrole.json",
+    "CheckID": "PASS_ROLE_WITH_STAR_IN_RESOURCE",
+    "Severity": "SECURITY_WARNING",`,
+		},
+		{
+			name: "LintSelectedChecks",
+			args: []string{"lint", "-checks", "REDUNDANT_ACTION", "testdata/passrole.json"},
+		},
+		{
+			name:       "LintUnknownCheck",
+			args:       []string{"lint", "-checks", "NOPE", "testdata/passrole.json"},
+			wantCode:   exitError,
+			wantStderr: `iampolicy: unknown check "NOPE"`,
+		},
+		{
+			name:       "EvalAllow",
+			args:       []string{"eval", "-action", "iam:PassRole", "-resource", "arn:aws:iam::111122223333:role/app", "testdata/passrole.json"},
+			wantStdout: "Allow\n  statement 0: Allow\n",
+		},
+		{
+			name:       "EvalImplicitDeny",
+			args:       []string{"eval", "-action", "s3:GetObject", "-resource", "arn:aws:s3:::examplebucket/a", "-principal", "arn:aws:iam::111122223333:role/app", "testdata/bucket.yaml"},
+			wantCode:   exitFailure,
+			wantStdout: "ImplicitDeny\n",
+		},
+		{
+			name: "EvalContextJSON",
+			args: []string{
+				"eval", "-format", "json", "-action", "s3:GetObject", "-resource", "arn:aws:s3:::examplebucket/a",
+				"-principal", "arn:aws:iam::111122223333:role/app", "-context", "aws:PrincipalOrgID=o-1234567890", "testdata/bucket.yaml",
+			},
+			wantStdout: `{
+  "Decision": "Allow",
+  "Matched": [
+    {
+      "Index": 0,
+      "Sid": "OrgRead",
+      "Effect": "Allow"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:       "EvalMissingAction",
+			args:       []string{"eval", "testdata/passrole.json"},
+			wantCode:   exitError,
+			wantStderr: "Usage: iampolicy eval [flags] [file]",
+		},
+		{
+			name:       "EvalBadContext",
+			args:       []string{"eval", "-action", "s3:GetObject", "-context", "novalue", "testdata/passrole.json"},
+			wantCode:   exitError,
+			wantStderr: `context must be key=value, got "novalue"`,
+		},
+		{
+			name:  "DiffEqual",
+			args:  []string{"diff", "testdata/passrole.json", "-"},
+			stdin: `{"Version": "2012-10-17", "Statement": [{"Resource": "*", "Effect": "Allow", "Action": "iam:PassRole"}]}`,
+		},
+		{
+			name:       "DiffChanged",
+			args:       []string{"diff", "testdata/passrole.json", "-"},
+			stdin:      `{"Version": "2012-10-17", "Statement": [{"Resource": "*", "Effect": "Deny", "Action": "iam:PassRole"}]}`,
+			wantCode:   exitFailure,
+			wantStdout: "--- testdata/passrole.json\n+++ -\n@@ -2,7 +2,7 @@\n   \"Statement\": [\n     {\n       \"Action\": \"iam:PassRole\",\n-      \"Effect\": \"Allow\",\n+      \"Effect\": \"Deny\",\n       \"Resource\": \"*\"\n     }\n   ],\n",
+		},
+		{
+			name:       "DiffSemantic",
+			args:       []string{"diff", "-semantic", "testdata/passrole.json", "-"},
+			stdin:      `{"Version": "2012-10-17", "Statement": [{"Resource": "*", "Effect": "Allow", "Action": ["iam:PassRole", "iam:GetRole"]}]}`,
+			wantCode:   exitFailure,
+			wantStdout: "/Statement/0/Action: added iam:GetRole (broadens access)\n",
+		},
+		{
+			name:  "DiffSemanticEqual",
+			args:  []string{"diff", "-semantic", "testdata/passrole.json", "-"},
+			stdin: `{"Version": "2012-10-17", "Statement": {"Resource": ["*"], "Effect": "Allow", "Action": ["IAM:passrole"]}}`,
+		},
+		{
+			name:       "DiffOneFile",
+			args:       []string{"diff", "testdata/passrole.json"},
+			wantCode:   exitError,
+			wantStderr: "Usage: iampolicy diff [flags] old new",
+		},
+		{
+			name:       "ConvertToYAML",
+			args:       []string{"convert", "-to", "yaml", "testdata/passrole.json"},
+			wantStdout: "Statement:\n  - Action: iam:PassRole\n    Effect: Allow\n    Resource: '*'\nVersion: \"2012-10-17\"\n",
+		},
+		{
+			name:       "ConvertToJSON",
+			args:       []string{"convert", "testdata/bucket.yaml"},
+			wantStdout: "\"Principal\": \"*\",",
+		},
+		{
+			name:       "ConvertUnknownTarget",
+			args:       []string{"convert", "-to", "toml", "testdata/bucket.yaml"},
+			wantCode:   exitError,
+			wantStderr: `iampolicy: unknown target "toml", must be "json", "yaml" or "hcl"`,
+		},
+		{
+			name:       "ConvertToHCL",
+			args:       []string{"convert", "-to", "hcl", "-name", "pass_role", "testdata/passrole.json"},
+			wantStdout: "data \"aws_iam_policy_document\" \"pass_role\" {\n  version = \"2012-10-17\"\n\n  statement {\n    effect    = \"Allow\"\n    actions   = [\"iam:PassRole\"]\n    resources = [\"*\"]\n  }\n}\n",
+		},
+		{
+			name:       "ConvertFromHCL",
+			args:       []string{"convert", "-to", "yaml", "testdata/readonly.tf"},
+			wantStdout: "Statement:\n  - Action:\n      - s3:GetObject\n      - s3:ListBucket\n    Effect: Allow\n    Resource: '*'\nVersion: \"2012-10-17\"\n",
+		},
+		{
+			name:       "ConvertFromHCLReference",
+			args:       []string{"convert", "-"},
+			stdin:      "# Bucket access.\ndata \"aws_iam_policy_document\" \"p\" {\n  statement {\n    resources = [aws_s3_bucket.b.arn]\n  }\n}\n",
+			wantCode:   exitError,
+			wantStderr: `iampolicy: -: line 4: only literal values are supported: found "aws_s3_bucket.b.arn"`,
+		},
+		{
+			name: "FmtHCL",
+			args: []string{"fmt", "-check", "testdata/readonly.tf"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			e := &env{stdin: strings.NewReader(tc.stdin), stdout: &stdout, stderr: &stderr}
+			code := run(e, tc.args)
+			if code != tc.wantCode {
+				t.Errorf("got exit code %d, want %d (stderr: %s)", code, tc.wantCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tc.wantStdout) {
+				t.Errorf("got stdout '%s', want '%s'", stdout.String(), tc.wantStdout)
+			}
+			if tc.wantStdout == "" && tc.wantCode == exitOK && stdout.Len() > 0 && tc.args[0] != "help" {
+				t.Errorf("got stdout '%s', want none", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tc.wantStderr) {
+				t.Errorf("got stderr '%s', want '%s'", stderr.String(), tc.wantStderr)
+			}
+		})
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "policy.json")
+	in := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"iam:PassRole","Resource":"*"}]}`
+	if err := os.WriteFile(name, []byte(in), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+	if code := run(e, []string{"fmt", "-w", name}); code != exitOK {
+		t.Fatalf("got exit code %d, want %d (stderr: %s)", code, exitOK, stderr.String())
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/passrole.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got '%s', want '%s'", string(got), string(want))
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("got stdout '%s', want none", stdout.String())
+	}
+}
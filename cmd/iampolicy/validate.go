@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// validationResult is a validation error in JSON output.
+type validationResult struct {
+	File string
+	*policy.ValidationError
+}
+
+func runValidate(e *env, args []string) int {
+	fs := newFlagSet(e, "validate", "[file ...]")
+	format := fs.String("format", formatText, "output format, text or json")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if err := checkFormat(*format); err != nil {
+		return errorf(e, "%v", err)
+	}
+
+	results := []validationResult{}
+	for _, name := range inputNames(fs.Args()) {
+		in, err := readInput(e, name)
+		if err != nil {
+			return errorf(e, "%v", err)
+		}
+		for _, verr := range in.policy.Validate() {
+			results = append(results, validationResult{File: name, ValidationError: verr})
+		}
+	}
+
+	if *format == formatJSON {
+		if err := writeJSON(e.stdout, results); err != nil {
+			return errorf(e, "%v", err)
+		}
+	} else {
+		for _, r := range results {
+			fmt.Fprintf(e.stdout, "%s:%s: %s (%s)\n", r.File, r.Path, r.Message, r.Code)
+		}
+	}
+	if len(results) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,246 @@
+/*
+Package condition implements the semantics of the [IAM condition operators]
+used in the Condition element of a policy statement.
+
+Operators are kept in a registry keyed by name. All of the operators defined by
+IAM are registered by default, and additional operators can be added with
+[Register]. The Null operator is built in rather than registered, as it tests
+whether a key is present in the request instead of comparing values. Conditions can be evaluated on their own with [Evaluate], or as a
+whole Condition element with [EvaluateBlock].
+
+[IAM condition operators]: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_elements_condition_operators.html
+*/
+package condition
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+const (
+	ErrorUnknownOperator = "unknown condition operator"
+	ErrorInvalidValue    = "invalid condition value"
+)
+
+// Context holds the values of the condition keys present in a request. Keys
+// are matched case-insensitively.
+type Context map[string][]string
+
+// Get returns the values of a condition key and whether the key is present.
+func (c Context) Get(key string) ([]string, bool) {
+	if values, ok := c[key]; ok {
+		return values, true
+	}
+	for k, values := range c {
+		if strings.EqualFold(k, key) {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+// MatchFunc compares a single value from a policy with a single value from a
+// request. It returns an error if the policy value is not valid for the
+// operator. Request values that are not valid for the operator do not match.
+type MatchFunc func(policyValue, requestValue string) (bool, error)
+
+// Operator is a condition operator.
+type Operator struct {
+	// Name is the name of the operator as used in a policy, such as
+	// "StringEquals".
+	Name string
+	// Match reports whether a request value matches a policy value.
+	Match MatchFunc
+	// Negated operators match when the request value matches none of the
+	// policy values, and when the key is not present in the request.
+	Negated bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Operator{}
+)
+
+// Register adds an operator to the registry, replacing any existing operator
+// with the same name. It panics if the operator is named Null, as the Null
+// operator is built in and cannot be replaced.
+func Register(op *Operator) {
+	if op.Name == policy.OperatorNull {
+		panic("condition: cannot register the built-in " + policy.OperatorNull + " operator")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[op.Name] = op
+}
+
+// Lookup returns the registered operator with the given name. The name must
+// not include the IfExists suffix. The built-in Null operator is not
+// registered, so it is not returned.
+func Lookup(name string) (*Operator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	op, ok := registry[name]
+	return op, ok
+}
+
+// Operators returns the sorted names of all registered operators and the
+// built-in Null operator.
+func Operators() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	resp := make([]string, 0, len(registry)+1)
+	for name := range registry {
+		resp = append(resp, name)
+	}
+	resp = append(resp, policy.OperatorNull)
+	sort.Strings(resp)
+	return resp
+}
+
+// Evaluate evaluates a single condition. The operator is the key used in the
+// policy's Condition element, such as "ForAnyValue:StringEqualsIfExists". The
+// request values are the values of the condition key in the request, and
+// present reports whether the key was present in the request at all.
+//
+// Without a qualifier, the condition is satisfied if any request value matches
+// any policy value, or for negated operators if no request value does. With
+// the ForAllValues qualifier, every request value must satisfy the operator,
+// which is trivially true when the key is missing or has no values. With the
+// ForAnyValue qualifier, at least one request value must satisfy the operator,
+// which is false when the key is missing or has no values.
+func Evaluate(operator string, value *policy.ConditionValue, requestValues []string, present bool) (bool, error) {
+	parsed, err := policy.ParseConditionOperator(operator)
+	if err != nil {
+		return false, err
+	}
+	policyValues := Strings(value)
+	if parsed.Name == policy.OperatorNull {
+		return evaluateNull(policyValues, present)
+	}
+	op, ok := Lookup(parsed.Name)
+	if !ok {
+		return false, fmt.Errorf("%s %q", ErrorUnknownOperator, operator)
+	}
+	if !present && parsed.IfExists {
+		return true, nil
+	}
+
+	switch parsed.Qualifier {
+	case policy.QualifierForAllValues:
+		for _, requestValue := range requestValues {
+			ok, err := satisfies(op, policyValues, requestValue)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case policy.QualifierForAnyValue:
+		for _, requestValue := range requestValues {
+			ok, err := satisfies(op, policyValues, requestValue)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	if !present {
+		return op.Negated, nil
+	}
+	matched := false
+	for _, requestValue := range requestValues {
+		matched, err = anyMatch(op, policyValues, requestValue)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			break
+		}
+	}
+	return matched != op.Negated, nil
+}
+
+// EvaluateBlock evaluates every condition in a Condition element against the
+// request context. Conditions are combined with a logical AND, so the block is
+// satisfied only if every condition is. An empty block is always satisfied.
+func EvaluateBlock(conditions policy.Condition, ctx Context) (bool, error) {
+	entries, err := conditions.Entries()
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		requestValues, present := ctx.Get(entry.Key)
+		ok, err := Evaluate(entry.Operator.String(), entry.Value, requestValues, present)
+		if err != nil {
+			return false, fmt.Errorf("%s %s: %w", entry.Operator, entry.Key, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// satisfies returns true if a single request value satisfies the operator:
+// it matches any policy value, or for negated operators it matches none.
+func satisfies(op *Operator, policyValues []string, requestValue string) (bool, error) {
+	matched, err := anyMatch(op, policyValues, requestValue)
+	if err != nil {
+		return false, err
+	}
+	return matched != op.Negated, nil
+}
+
+// anyMatch returns true if the request value matches any policy value.
+func anyMatch(op *Operator, policyValues []string, requestValue string) (bool, error) {
+	for _, policyValue := range policyValues {
+		ok, err := op.Match(policyValue, requestValue)
+		if err != nil {
+			return false, fmt.Errorf("%s %q for %s: %w", ErrorInvalidValue, policyValue, op.Name, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// evaluateNull implements the Null operator, which checks whether a key is
+// absent ("true") or present ("false") in the request.
+func evaluateNull(policyValues []string, present bool) (bool, error) {
+	for _, policyValue := range policyValues {
+		isNull, err := strconv.ParseBool(policyValue)
+		if err != nil {
+			return false, fmt.Errorf("%s %q for %s: %w", ErrorInvalidValue, policyValue, policy.OperatorNull, err)
+		}
+		if isNull != present {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Strings returns the values of a ConditionValue as strings, the form in
+// which values appear in a request context. Numbers are returned as written
+// in the policy, so that large numbers are not rounded.
+func Strings(value *policy.ConditionValue) []string {
+	if value == nil {
+		return nil
+	}
+	strs, bools, _ := value.Values()
+	nums := value.Numbers()
+	resp := make([]string, 0, len(strs)+len(bools)+len(nums))
+	resp = append(resp, strs...)
+	for _, b := range bools {
+		resp = append(resp, strconv.FormatBool(b))
+	}
+	for _, n := range nums {
+		resp = append(resp, n.String())
+	}
+	return resp
+}
@@ -0,0 +1,321 @@
+package condition
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func TestEvaluateMissingKey(t *testing.T) {
+	cases := []struct {
+		name     string
+		operator string
+		value    *policy.ConditionValue
+		want     bool
+	}{
+		{
+			name:     "Positive",
+			operator: policy.OperatorStringEquals,
+			value:    policy.NewConditionValueString(true, "a"),
+			want:     false,
+		},
+		{
+			name:     "Negated",
+			operator: policy.OperatorStringNotEquals,
+			value:    policy.NewConditionValueString(true, "a"),
+			want:     true,
+		},
+		{
+			name:     "IfExists",
+			operator: policy.OperatorStringEquals + policy.OperatorSuffixIfExists,
+			value:    policy.NewConditionValueString(true, "a"),
+			want:     true,
+		},
+		{
+			name:     "NullTrue",
+			operator: policy.OperatorNull,
+			value:    policy.NewConditionValueString(true, "true"),
+			want:     true,
+		},
+		{
+			name:     "NullFalse",
+			operator: policy.OperatorNull,
+			value:    policy.NewConditionValueBool(true, false),
+			want:     false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Evaluate(tc.operator, tc.value, nil, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateIfExistsPresent(t *testing.T) {
+	got, err := Evaluate("StringEqualsIfExists", policy.NewConditionValueString(true, "a"), []string{"b"}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got {
+		t.Errorf("got '%t', want '%t'", got, false)
+	}
+}
+
+func TestEvaluateUnknownOperator(t *testing.T) {
+	_, err := Evaluate("StringEqualz", policy.NewConditionValueString(true, "a"), []string{"a"}, true)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	want := `unknown condition operator "StringEqualz"`
+	if err.Error() != want {
+		t.Errorf("got '%s', want '%s'", err.Error(), want)
+	}
+}
+
+func TestEvaluateNumberText(t *testing.T) {
+	for _, number := range []string{"12345678901234567890", "1.50"} {
+		t.Run(number, func(t *testing.T) {
+			value := &policy.ConditionValue{}
+			if err := json.Unmarshal([]byte(number), value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := Evaluate(policy.OperatorStringEquals, value, []string{number}, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got {
+				t.Errorf("got '%t', want '%t'", got, true)
+			}
+		})
+	}
+}
+
+func TestEvaluateBlock(t *testing.T) {
+	conditions := map[string]map[string]*policy.ConditionValue{
+		policy.OperatorStringEquals: {
+			"aws:PrincipalTag/team": policy.NewConditionValueString(true, "platform"),
+		},
+		policy.OperatorIpAddress: {
+			"aws:SourceIp": policy.NewConditionValueString(false, "10.0.0.0/8", "192.168.0.0/16"),
+		},
+	}
+	cases := []struct {
+		name string
+		ctx  Context
+		want bool
+	}{
+		{
+			name: "AllSatisfied",
+			ctx:  Context{"aws:PrincipalTag/team": {"platform"}, "aws:SourceIp": {"192.168.1.1"}},
+			want: true,
+		},
+		{
+			name: "KeysAreCaseInsensitive",
+			ctx:  Context{"AWS:PRINCIPALTAG/TEAM": {"platform"}, "aws:sourceip": {"10.1.1.1"}},
+			want: true,
+		},
+		{
+			name: "OneUnsatisfied",
+			ctx:  Context{"aws:PrincipalTag/team": {"platform"}, "aws:SourceIp": {"172.16.0.1"}},
+			want: false,
+		},
+		{
+			name: "MissingKey",
+			ctx:  Context{"aws:SourceIp": {"10.1.1.1"}},
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EvaluateBlock(conditions, tc.ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(&Operator{
+		Name: "StringHasPrefix",
+		Match: func(policyValue, requestValue string) (bool, error) {
+			return strings.HasPrefix(requestValue, policyValue), nil
+		},
+	})
+	op, ok := Lookup("StringHasPrefix")
+	if !ok || op.Name != "StringHasPrefix" {
+		t.Fatalf("expected registered operator")
+	}
+	found := false
+	for _, name := range Operators() {
+		if name == "StringHasPrefix" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("registered operator missing from Operators()")
+	}
+	got, err := Evaluate("StringHasPrefixIfExists", policy.NewConditionValueString(true, "prod-"), []string{"prod-db"}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got {
+		t.Errorf("got '%t', want '%t'", got, true)
+	}
+}
+
+func TestOperatorsNull(t *testing.T) {
+	if _, ok := Lookup(policy.OperatorNull); ok {
+		t.Errorf("expected %s not to be registered", policy.OperatorNull)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected Register to panic for %s", policy.OperatorNull)
+			}
+		}()
+		Register(&Operator{Name: policy.OperatorNull, Match: stringEquals})
+	}()
+	count := 0
+	for _, name := range Operators() {
+		if name == policy.OperatorNull {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("got '%d', want '%d'", count, 1)
+	}
+}
+
+func TestEvaluateSetOperators(t *testing.T) {
+	allowedTags := policy.NewConditionValueString(false, "environment", "cost-center")
+	cases := []struct {
+		name     string
+		operator string
+		request  []string
+		present  bool
+		want     bool
+	}{
+		{
+			name:     "ForAllValuesSubset",
+			operator: "ForAllValues:StringEquals",
+			request:  []string{"environment"},
+			present:  true,
+			want:     true,
+		},
+		{
+			name:     "ForAllValuesNotSubset",
+			operator: "ForAllValues:StringEquals",
+			request:  []string{"environment", "owner"},
+			present:  true,
+			want:     false,
+		},
+		{
+			name:     "ForAllValuesEmptySet",
+			operator: "ForAllValues:StringEquals",
+			request:  []string{},
+			present:  true,
+			want:     true,
+		},
+		{
+			name:     "ForAllValuesMissingKey",
+			operator: "ForAllValues:StringEquals",
+			present:  false,
+			want:     true,
+		},
+		{
+			name:     "ForAllValuesNegated",
+			operator: "ForAllValues:StringNotEquals",
+			request:  []string{"owner", "team"},
+			present:  true,
+			want:     true,
+		},
+		{
+			name:     "ForAllValuesNegatedOneMatch",
+			operator: "ForAllValues:StringNotEquals",
+			request:  []string{"owner", "environment"},
+			present:  true,
+			want:     false,
+		},
+		{
+			name:     "ForAnyValueOneMatch",
+			operator: "ForAnyValue:StringEquals",
+			request:  []string{"owner", "cost-center"},
+			present:  true,
+			want:     true,
+		},
+		{
+			name:     "ForAnyValueNoMatch",
+			operator: "ForAnyValue:StringEquals",
+			request:  []string{"owner"},
+			present:  true,
+			want:     false,
+		},
+		{
+			name:     "ForAnyValueEmptySet",
+			operator: "ForAnyValue:StringEquals",
+			request:  []string{},
+			present:  true,
+			want:     false,
+		},
+		{
+			name:     "ForAnyValueMissingKey",
+			operator: "ForAnyValue:StringEquals",
+			present:  false,
+			want:     false,
+		},
+		{
+			name:     "ForAnyValueMissingKeyIfExists",
+			operator: "ForAnyValue:StringEqualsIfExists",
+			present:  false,
+			want:     true,
+		},
+		{
+			name:     "ForAnyValueNegated",
+			operator: "ForAnyValue:StringNotEquals",
+			request:  []string{"environment", "owner"},
+			present:  true,
+			want:     true,
+		},
+		{
+			name:     "ForAnyValueLike",
+			operator: "ForAnyValue:StringLike",
+			request:  []string{"cost-*"},
+			present:  true,
+			want:     false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Evaluate(tc.operator, allowedTags, tc.request, tc.present)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateInvalidQualifier(t *testing.T) {
+	_, err := Evaluate("ForSomeValues:StringEquals", policy.NewConditionValueString(true, "a"), []string{"a"}, true)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	want := `invalid condition operator "ForSomeValues:StringEquals": unknown qualifier "ForSomeValues"`
+	if err.Error() != want {
+		t.Errorf("got '%s', want '%s'", err.Error(), want)
+	}
+}
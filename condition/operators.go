@@ -0,0 +1,193 @@
+package condition
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micahhausler/aws-iam-policy/arn"
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func init() {
+	for _, op := range []*Operator{
+		{Name: policy.OperatorStringEquals, Match: stringEquals},
+		{Name: policy.OperatorStringNotEquals, Match: stringEquals, Negated: true},
+		{Name: policy.OperatorStringEqualsIgnoreCase, Match: stringEqualsIgnoreCase},
+		{Name: policy.OperatorStringNotEqualsIgnoreCase, Match: stringEqualsIgnoreCase, Negated: true},
+		{Name: policy.OperatorStringLike, Match: stringLike},
+		{Name: policy.OperatorStringNotLike, Match: stringLike, Negated: true},
+
+		{Name: policy.OperatorNumericEquals, Match: numeric(func(c int) bool { return c == 0 })},
+		{Name: policy.OperatorNumericNotEquals, Match: numeric(func(c int) bool { return c == 0 }), Negated: true},
+		{Name: policy.OperatorNumericLessThan, Match: numeric(func(c int) bool { return c < 0 })},
+		{Name: policy.OperatorNumericLessThanEquals, Match: numeric(func(c int) bool { return c <= 0 })},
+		{Name: policy.OperatorNumericGreaterThan, Match: numeric(func(c int) bool { return c > 0 })},
+		{Name: policy.OperatorNumericGreaterThanEquals, Match: numeric(func(c int) bool { return c >= 0 })},
+
+		{Name: policy.OperatorDateEquals, Match: date(func(c int) bool { return c == 0 })},
+		{Name: policy.OperatorDateNotEquals, Match: date(func(c int) bool { return c == 0 }), Negated: true},
+		{Name: policy.OperatorDateLessThan, Match: date(func(c int) bool { return c < 0 })},
+		{Name: policy.OperatorDateLessThanEquals, Match: date(func(c int) bool { return c <= 0 })},
+		{Name: policy.OperatorDateGreaterThan, Match: date(func(c int) bool { return c > 0 })},
+		{Name: policy.OperatorDateGreaterThanEquals, Match: date(func(c int) bool { return c >= 0 })},
+
+		{Name: policy.OperatorBool, Match: boolEquals},
+		{Name: policy.OperatorBinaryEquals, Match: binaryEquals},
+
+		{Name: policy.OperatorIpAddress, Match: ipAddress},
+		{Name: policy.OperatorNotIpAddress, Match: ipAddress, Negated: true},
+
+		{Name: policy.OperatorArnEquals, Match: arnLike},
+		{Name: policy.OperatorArnLike, Match: arnLike},
+		{Name: policy.OperatorArnNotEquals, Match: arnLike, Negated: true},
+		{Name: policy.OperatorArnNotLike, Match: arnLike, Negated: true},
+	} {
+		Register(op)
+	}
+}
+
+func stringEquals(policyValue, requestValue string) (bool, error) {
+	return policyValue == requestValue, nil
+}
+
+func stringEqualsIgnoreCase(policyValue, requestValue string) (bool, error) {
+	return strings.EqualFold(policyValue, requestValue), nil
+}
+
+func stringLike(policyValue, requestValue string) (bool, error) {
+	return wildcard.Match(policyValue, requestValue), nil
+}
+
+// numeric returns a MatchFunc that compares the request value to the policy
+// value. The comparison function is passed -1, 0 or 1 if the request value is
+// less than, equal to or greater than the policy value.
+func numeric(cmp func(int) bool) MatchFunc {
+	return func(policyValue, requestValue string) (bool, error) {
+		want, err := strconv.ParseFloat(policyValue, 64)
+		if err != nil {
+			return false, err
+		}
+		got, err := strconv.ParseFloat(requestValue, 64)
+		if err != nil {
+			return false, nil
+		}
+		switch {
+		case got < want:
+			return cmp(-1), nil
+		case got > want:
+			return cmp(1), nil
+		default:
+			return cmp(0), nil
+		}
+	}
+}
+
+// date returns a MatchFunc that compares the request date to the policy date.
+// The comparison function is passed -1, 0 or 1 if the request date is before,
+// equal to or after the policy date.
+func date(cmp func(int) bool) MatchFunc {
+	return func(policyValue, requestValue string) (bool, error) {
+		want, err := parseDate(policyValue)
+		if err != nil {
+			return false, err
+		}
+		got, err := parseDate(requestValue)
+		if err != nil {
+			return false, nil
+		}
+		switch {
+		case got.Before(want):
+			return cmp(-1), nil
+		case got.After(want):
+			return cmp(1), nil
+		default:
+			return cmp(0), nil
+		}
+	}
+}
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseDate parses the ISO 8601 and epoch seconds date formats accepted by
+// IAM.
+func parseDate(s string) (time.Time, error) {
+	if epoch, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+	var err error
+	for _, layout := range dateLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func boolEquals(policyValue, requestValue string) (bool, error) {
+	want, err := strconv.ParseBool(policyValue)
+	if err != nil {
+		return false, err
+	}
+	got, err := strconv.ParseBool(requestValue)
+	if err != nil {
+		return false, nil
+	}
+	return want == got, nil
+}
+
+// binaryEquals compares base64-encoded policy and request values byte for byte.
+func binaryEquals(policyValue, requestValue string) (bool, error) {
+	want, err := base64.StdEncoding.DecodeString(policyValue)
+	if err != nil {
+		return false, err
+	}
+	got, err := base64.StdEncoding.DecodeString(requestValue)
+	if err != nil {
+		return false, nil
+	}
+	return bytes.Equal(want, got), nil
+}
+
+// ipAddress matches a request IP address against a policy IP address or CIDR
+// block.
+func ipAddress(policyValue, requestValue string) (bool, error) {
+	var prefix netip.Prefix
+	if strings.Contains(policyValue, "/") {
+		var err error
+		prefix, err = netip.ParsePrefix(policyValue)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		addr, err := netip.ParseAddr(policyValue)
+		if err != nil {
+			return false, err
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	addr, err := netip.ParseAddr(requestValue)
+	if err != nil {
+		return false, nil
+	}
+	return prefix.Masked().Contains(addr.Unmap()), nil
+}
+
+// arnLike matches the request ARN against the policy ARN segment by segment,
+// so wildcards in one segment never match across a colon into another. IAM
+// treats ArnEquals and ArnLike identically.
+func arnLike(policyValue, requestValue string) (bool, error) {
+	return arn.Match(policyValue, requestValue), nil
+}
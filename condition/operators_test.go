@@ -0,0 +1,117 @@
+package condition
+
+import (
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func TestOperators(t *testing.T) {
+	cases := []struct {
+		name     string
+		operator string
+		value    *policy.ConditionValue
+		request  []string
+		want     bool
+	}{
+		{name: "StringEquals", operator: policy.OperatorStringEquals, value: policy.NewConditionValueString(true, "a", "b"), request: []string{"b"}, want: true},
+		{name: "StringEqualsCase", operator: policy.OperatorStringEquals, value: policy.NewConditionValueString(true, "a"), request: []string{"A"}, want: false},
+		{name: "StringNotEquals", operator: policy.OperatorStringNotEquals, value: policy.NewConditionValueString(true, "a", "b"), request: []string{"c"}, want: true},
+		{name: "StringNotEqualsMatch", operator: policy.OperatorStringNotEquals, value: policy.NewConditionValueString(true, "a", "b"), request: []string{"a"}, want: false},
+		{name: "StringEqualsIgnoreCase", operator: policy.OperatorStringEqualsIgnoreCase, value: policy.NewConditionValueString(true, "Admin"), request: []string{"ADMIN"}, want: true},
+		{name: "StringNotEqualsIgnoreCase", operator: policy.OperatorStringNotEqualsIgnoreCase, value: policy.NewConditionValueString(true, "Admin"), request: []string{"admin"}, want: false},
+		{name: "StringLike", operator: policy.OperatorStringLike, value: policy.NewConditionValueString(true, "home/${aws:username}/*", "logs/*"), request: []string{"logs/2023/01"}, want: true},
+		{name: "StringLikeQuestion", operator: policy.OperatorStringLike, value: policy.NewConditionValueString(true, "v?"), request: []string{"v10"}, want: false},
+		{name: "StringNotLike", operator: policy.OperatorStringNotLike, value: policy.NewConditionValueString(true, "logs/*"), request: []string{"data/1"}, want: true},
+
+		{name: "NumericEquals", operator: policy.OperatorNumericEquals, value: policy.NewConditionValueFloat(true, 10), request: []string{"10.0"}, want: true},
+		{name: "NumericEqualsString", operator: policy.OperatorNumericEquals, value: policy.NewConditionValueString(true, "10"), request: []string{"10"}, want: true},
+		{name: "NumericNotEquals", operator: policy.OperatorNumericNotEquals, value: policy.NewConditionValueFloat(true, 10), request: []string{"11"}, want: true},
+		{name: "NumericLessThan", operator: policy.OperatorNumericLessThan, value: policy.NewConditionValueFloat(true, 10), request: []string{"9"}, want: true},
+		{name: "NumericLessThanEqual", operator: policy.OperatorNumericLessThan, value: policy.NewConditionValueFloat(true, 10), request: []string{"10"}, want: false},
+		{name: "NumericLessThanEquals", operator: policy.OperatorNumericLessThanEquals, value: policy.NewConditionValueFloat(true, 10), request: []string{"10"}, want: true},
+		{name: "NumericGreaterThan", operator: policy.OperatorNumericGreaterThan, value: policy.NewConditionValueFloat(true, 10), request: []string{"10.5"}, want: true},
+		{name: "NumericGreaterThanEquals", operator: policy.OperatorNumericGreaterThanEquals, value: policy.NewConditionValueFloat(true, 10), request: []string{"9.99"}, want: false},
+		{name: "NumericInvalidRequest", operator: policy.OperatorNumericEquals, value: policy.NewConditionValueFloat(true, 10), request: []string{"ten"}, want: false},
+
+		{name: "DateEquals", operator: policy.OperatorDateEquals, value: policy.NewConditionValueString(true, "2020-01-01T00:00:00Z"), request: []string{"1577836800"}, want: true},
+		{name: "DateNotEquals", operator: policy.OperatorDateNotEquals, value: policy.NewConditionValueString(true, "2020-01-01"), request: []string{"2020-01-02T00:00:00Z"}, want: true},
+		{name: "DateLessThan", operator: policy.OperatorDateLessThan, value: policy.NewConditionValueString(true, "2020-01-01T00:00:00Z"), request: []string{"2019-12-31T23:59:59Z"}, want: true},
+		{name: "DateLessThanEquals", operator: policy.OperatorDateLessThanEquals, value: policy.NewConditionValueString(true, "2020-01-01T00:00:00Z"), request: []string{"2020-01-01T00:00:00.000Z"}, want: true},
+		{name: "DateGreaterThan", operator: policy.OperatorDateGreaterThan, value: policy.NewConditionValueString(true, "2020-01-01T00:00:00Z"), request: []string{"2019-06-01T00:00:00Z"}, want: false},
+		{name: "DateGreaterThanEquals", operator: policy.OperatorDateGreaterThanEquals, value: policy.NewConditionValueString(true, "2020-01-01T00:00:00Z"), request: []string{"2020-01-01T01:00:00+01:00"}, want: true},
+
+		{name: "BoolTrue", operator: policy.OperatorBool, value: policy.NewConditionValueBool(true, true), request: []string{"true"}, want: true},
+		{name: "BoolString", operator: policy.OperatorBool, value: policy.NewConditionValueString(true, "false"), request: []string{"true"}, want: false},
+		{name: "BinaryEquals", operator: policy.OperatorBinaryEquals, value: policy.NewConditionValueString(true, "QmluYXJ5VmFsdWVJbkJhc2U2NA=="), request: []string{"QmluYXJ5VmFsdWVJbkJhc2U2NA=="}, want: true},
+		{name: "BinaryNotEquals", operator: policy.OperatorBinaryEquals, value: policy.NewConditionValueString(true, "QmluYXJ5VmFsdWVJbkJhc2U2NA=="), request: []string{"YWJj"}, want: false},
+
+		{name: "IpAddressCIDR", operator: policy.OperatorIpAddress, value: policy.NewConditionValueString(true, "203.0.113.0/24"), request: []string{"203.0.113.7"}, want: true},
+		{name: "IpAddressSingle", operator: policy.OperatorIpAddress, value: policy.NewConditionValueString(true, "203.0.113.7"), request: []string{"203.0.113.8"}, want: false},
+		{name: "IpAddressIPv6", operator: policy.OperatorIpAddress, value: policy.NewConditionValueString(true, "2001:DB8:1234:5678::/64"), request: []string{"2001:db8:1234:5678::1"}, want: true},
+		{name: "NotIpAddress", operator: policy.OperatorNotIpAddress, value: policy.NewConditionValueString(true, "203.0.113.0/24"), request: []string{"198.51.100.1"}, want: true},
+
+		{name: "ArnEquals", operator: policy.OperatorArnEquals, value: policy.NewConditionValueString(true, "arn:aws:sns:us-east-1:111122223333:topic"), request: []string{"arn:aws:sns:us-east-1:111122223333:topic"}, want: true},
+		{name: "ArnLike", operator: policy.OperatorArnLike, value: policy.NewConditionValueString(true, "arn:aws:iam::*:role/admin-*"), request: []string{"arn:aws:iam::111122223333:role/admin-ops"}, want: true},
+		{name: "ArnLikeSegment", operator: policy.OperatorArnLike, value: policy.NewConditionValueString(true, "arn:aws:s3*"), request: []string{"arn:aws:s3:::bucket"}, want: true},
+		{name: "ArnLikeNoCrossSegment", operator: policy.OperatorArnLike, value: policy.NewConditionValueString(true, "arn:aws:iam::*:role/x"), request: []string{"arn:aws:iam::1:2:role/x"}, want: false},
+		{name: "ArnNotEquals", operator: policy.OperatorArnNotEquals, value: policy.NewConditionValueString(true, "arn:aws:sns:us-east-1:111122223333:topic"), request: []string{"arn:aws:sns:us-east-1:111122223333:other"}, want: true},
+		{name: "ArnNotLike", operator: policy.OperatorArnNotLike, value: policy.NewConditionValueString(true, "arn:aws:iam::*:role/admin-*"), request: []string{"arn:aws:iam::111122223333:role/admin-ops"}, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Evaluate(tc.operator, tc.value, tc.request, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOperatorsInvalidPolicyValue(t *testing.T) {
+	cases := []struct {
+		name     string
+		operator string
+		value    *policy.ConditionValue
+		wantErr  string
+	}{
+		{
+			name:     "Numeric",
+			operator: policy.OperatorNumericEquals,
+			value:    policy.NewConditionValueString(true, "ten"),
+			wantErr:  `invalid condition value "ten" for NumericEquals: strconv.ParseFloat: parsing "ten": invalid syntax`,
+		},
+		{
+			name:     "Date",
+			operator: policy.OperatorDateLessThan,
+			value:    policy.NewConditionValueString(true, "tomorrow"),
+			wantErr:  `invalid condition value "tomorrow" for DateLessThan: parsing time "tomorrow" as "2006-01-02": cannot parse "tomorrow" as "2006"`,
+		},
+		{
+			name:     "Bool",
+			operator: policy.OperatorBool,
+			value:    policy.NewConditionValueString(true, "yes"),
+			wantErr:  `invalid condition value "yes" for Bool: strconv.ParseBool: parsing "yes": invalid syntax`,
+		},
+		{
+			name:     "IpAddress",
+			operator: policy.OperatorIpAddress,
+			value:    policy.NewConditionValueString(true, "10.0.0.0/33"),
+			wantErr:  `invalid condition value "10.0.0.0/33" for IpAddress: netip.ParsePrefix("10.0.0.0/33"): prefix length out of range`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Evaluate(tc.operator, tc.value, []string{"1"}, true)
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if err.Error() != tc.wantErr {
+				t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
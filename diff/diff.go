@@ -0,0 +1,429 @@
+/*
+Package diff compares two policies by the access they grant, rather than by
+their text. Statements of the old and new policy are paired, and the actions,
+resources, principals and condition constraints of each pair are compared:
+
+	result := diff.Compare(old, new, catalog.Default())
+	for _, c := range result.Changes {
+		fmt.Println(c)
+	}
+	// /Statement/0/Action: added s3:PutObject (broadens access)
+
+Action patterns are expanded with an action catalog before they are compared,
+so that replacing "s3:Get*" with "s3:*" is reported as the actions that were
+added. Patterns the catalog cannot list every match of, such as "*",
+"dynamodb:*" for a service missing from the catalog, or "ec2:Describe*" for a
+partial service, are not expanded (see catalog.Catalog.ExpandActions). Values
+that are not expanded are compared as patterns: a value that is matched by a
+value on the other side, such as "ec2:*" against "*", or
+"arn:aws:s3:::bucket/logs/*" against "arn:aws:s3:::bucket/*", is not
+reported.
+
+Each change is marked when it broadens access: it grants access the old policy
+did not, or it removes or loosens a restriction. Compare does not evaluate
+how statements interact, so a change is reported as broadening even if another
+statement already grants or denies the same access.
+*/
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/catalog"
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// Op is the kind of a change.
+type Op string
+
+// Change operations.
+const (
+	Added   Op = "added"
+	Removed Op = "removed"
+)
+
+// Elements of a statement that changes are reported on, in addition to the
+// policy element names such as "Action" and "NotResource".
+const (
+	ElementStatement = "Statement"
+	ElementCondition = "Condition"
+)
+
+// Change is a difference in the access granted by two policies.
+type Change struct {
+	// Path is the JSON pointer (RFC 6901) of the changed element, such as
+	// "/Statement/0/Action". It points into the new policy, or into the old
+	// policy for statements that were removed.
+	Path string
+	// Element is the name of the changed statement element, such as
+	// "Action" or "NotPrincipal", or ElementStatement for statements that
+	// were added or removed as a whole.
+	Element string
+	Op      Op
+	// Value is the value that was added or removed. Principals are written
+	// as "<kind>:<identifier>", such as "AWS:111122223333", and conditions as
+	// "<operator> <key> <value>". For whole statements it is the Sid.
+	Value string
+	// Effect is the Effect of the statement holding the change.
+	Effect string
+	// Broadens is true if the change grants access the old policy did not.
+	Broadens bool
+}
+
+func (c *Change) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s ", c.Path, c.Op)
+	if c.Element == ElementStatement {
+		b.WriteString(strings.ToLower(c.Effect) + " statement")
+		if c.Value != "" {
+			fmt.Fprintf(&b, " %q", c.Value)
+		}
+	} else {
+		b.WriteString(c.Value)
+	}
+	if c.Broadens {
+		b.WriteString(" (broadens access)")
+	}
+	return b.String()
+}
+
+// Result is the result of comparing two policies.
+type Result struct {
+	// Changes lists the differences, ordered by statement in the new
+	// policy, with removed statements last.
+	Changes []*Change
+}
+
+// Equal returns true if no differences were found.
+func (r *Result) Equal() bool {
+	return len(r.Changes) == 0
+}
+
+// Broadened returns true if any change broadens access.
+func (r *Result) Broadened() bool {
+	for _, c := range r.Changes {
+		if c.Broadens {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare compares the access granted by two policies. Action patterns are
+// expanded with the catalog, which may be nil to compare them as patterns
+// only. A nil policy is treated as a policy without statements.
+func Compare(old, new *policy.Policy, c *catalog.Catalog) *Result {
+	cmp := &comparer{catalog: c, result: &Result{Changes: []*Change{}}}
+	oldStatements := statements(old)
+	newStatements := statements(new)
+	pairs := pairStatements(oldStatements, newStatements)
+
+	paired := make([]bool, len(oldStatements))
+	for j, ns := range newStatements {
+		i, ok := pairs[j]
+		if !ok {
+			cmp.statement(Added, ns)
+			continue
+		}
+		paired[i] = true
+		cmp.compareStatements(oldStatements[i], ns)
+	}
+	for i, os := range oldStatements {
+		if !paired[i] {
+			cmp.statement(Removed, os)
+		}
+	}
+	return cmp.result
+}
+
+// statement is a statement of a policy along with its JSON pointer.
+type statement struct {
+	path string
+	*policy.Statement
+}
+
+func statements(p *policy.Policy) []statement {
+	if p == nil || p.Statements == nil {
+		return nil
+	}
+	values := p.Statements.Values()
+	resp := make([]statement, len(values))
+	for i := range values {
+		resp[i] = statement{path: p.Statements.Pointer(i), Statement: &values[i]}
+	}
+	return resp
+}
+
+// pairStatements matches the statements of the new policy to those of the
+// old policy, returning the index of the old statement for each paired new
+// statement. Statements are paired by Sid, then when they are identical, and
+// then in order among the remaining statements. Statements are only paired if
+// they have the same Effect.
+func pairStatements(old, new []statement) map[int]int {
+	pairs := map[int]int{}
+	used := make([]bool, len(old))
+	pair := func(match func(o, n statement) bool) {
+		for j, n := range new {
+			if _, ok := pairs[j]; ok {
+				continue
+			}
+			for i, o := range old {
+				if !used[i] && o.Effect == n.Effect && match(o, n) {
+					pairs[j] = i
+					used[i] = true
+					break
+				}
+			}
+		}
+	}
+	pair(func(o, n statement) bool { return o.Sid != "" && o.Sid == n.Sid })
+	pair(func(o, n statement) bool {
+		ob, oerr := json.Marshal(o.Statement)
+		nb, nerr := json.Marshal(n.Statement)
+		return oerr == nil && nerr == nil && string(ob) == string(nb)
+	})
+	// Statements with a Sid that was not matched were renamed or replaced,
+	// so they are only paired with each other in order.
+	pair(func(o, n statement) bool { return true })
+	return pairs
+}
+
+type comparer struct {
+	catalog *catalog.Catalog
+	result  *Result
+}
+
+func (c *comparer) add(s statement, element string, op Op, value string, broadens bool) {
+	path := s.path
+	if element != ElementStatement {
+		path += "/" + element
+	}
+	c.result.Changes = append(c.result.Changes, &Change{
+		Path:     path,
+		Element:  element,
+		Op:       op,
+		Value:    value,
+		Effect:   s.Effect,
+		Broadens: broadens,
+	})
+}
+
+// statement reports a statement that was added or removed as a whole. Adding
+// an Allow statement or removing a Deny statement broadens access.
+func (c *comparer) statement(op Op, s statement) {
+	c.add(s, ElementStatement, op, s.Sid, (op == Added) == (s.Effect == policy.EffectAllow))
+}
+
+// compareStatements reports the differences between two statements with the
+// same Effect.
+func (c *comparer) compareStatements(old, new statement) {
+	// Adding values to an element such as Action widens the statement,
+	// while adding values to its Not form narrows it.
+	c.compareValues(new, "Action", c.actions(old.Action), c.actions(new.Action), true, wildcard.SubsumesFold)
+	c.compareValues(new, "NotAction", c.actions(old.NotAction), c.actions(new.NotAction), false, wildcard.SubsumesFold)
+	c.compareValues(new, "Resource", values(old.Resource), values(new.Resource), true, wildcard.Subsumes)
+	c.compareValues(new, "NotResource", values(old.NotResource), values(new.NotResource), false, wildcard.Subsumes)
+	c.compareValues(new, "Principal", principals(old.Principal), principals(new.Principal), true, subsumesPrincipal)
+	c.compareValues(new, "NotPrincipal", principals(old.NotPrincipal), principals(new.NotPrincipal), false, subsumesPrincipal)
+	c.compareConditions(old, new)
+}
+
+// compareValues reports the values of an element that were added or removed.
+// A value is not reported if it is subsumed by a value on the other side.
+// widens is true if adding values to the element makes the statement match
+// more requests. A widened Allow statement or a narrowed Deny statement
+// broadens access. A missing element matches every request, so it is compared
+// as the "*" value, and a missing Not element excludes nothing.
+func (c *comparer) compareValues(s statement, element string, old, new []string, widens bool, subsumes func(pattern, other string) bool) {
+	if old == nil && new == nil {
+		return
+	}
+	allow := s.Effect == policy.EffectAllow
+	if !widens {
+		if old == nil {
+			old = []string{}
+		}
+		if new == nil {
+			new = []string{}
+		}
+	} else {
+		if old == nil {
+			old = []string{"*"}
+		}
+		if new == nil {
+			new = []string{"*"}
+		}
+	}
+	for _, v := range new {
+		if !subsumedBy(v, old, subsumes) {
+			c.add(s, element, Added, v, widens == allow)
+		}
+	}
+	for _, v := range old {
+		if !subsumedBy(v, new, subsumes) {
+			c.add(s, element, Removed, v, widens != allow)
+		}
+	}
+}
+
+func subsumedBy(value string, patterns []string, subsumes func(pattern, other string) bool) bool {
+	for _, p := range patterns {
+		if subsumes(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// actions returns the actions of an element, expanded with the catalog.
+// Patterns the catalog cannot expand are kept, and compared as patterns.
+func (c *comparer) actions(s *policy.StringOrSlice) []string {
+	if c.catalog != nil {
+		s = c.catalog.ExpandActions(s)
+	}
+	return values(s)
+}
+
+func values(s *policy.StringOrSlice) []string {
+	if s == nil {
+		return nil
+	}
+	return s.Values()
+}
+
+// principals returns the principals of an element as "<kind>:<identifier>",
+// or "*" for every principal.
+func principals(p *policy.Principal) []string {
+	if p == nil {
+		return nil
+	}
+	resp := []string{}
+	for _, kind := range p.Kinds() {
+		var ids *policy.StringOrSlice
+		switch kind {
+		case policy.PrincipalKindAll:
+			resp = append(resp, policy.PrincipalAll)
+			continue
+		case policy.PrincipalKindAWS:
+			ids = p.AWS()
+		case policy.PrincipalKindCanonical:
+			ids = p.CanonicalUser()
+		case policy.PrincipalKindFederated:
+			ids = p.Federated()
+		case policy.PrincipalKindService:
+			ids = p.Service()
+		}
+		for _, id := range ids.Values() {
+			resp = append(resp, kind+":"+id)
+		}
+	}
+	return resp
+}
+
+// subsumesPrincipal reports whether a principal matches every principal
+// matched by other. The "*" principal, and "AWS:*", match every principal.
+func subsumesPrincipal(pattern, other string) bool {
+	if pattern == policy.PrincipalAll || pattern == other {
+		return true
+	}
+	kind, id, _ := strings.Cut(pattern, ":")
+	otherKind, _, _ := strings.Cut(other, ":")
+	return kind == policy.PrincipalKindAWS && id == policy.PrincipalAll && otherKind != policy.PrincipalAll
+}
+
+// compareConditions reports the condition values that were added or removed.
+// Adding a condition key restricts a statement, while adding a value to an
+// existing key loosens it, unless the operator is negated, such as
+// StringNotEquals, where each value is another restriction.
+func (c *comparer) compareConditions(old, new statement) {
+	allow := new.Effect == policy.EffectAllow
+	report := func(s statement, op Op, from, to policy.Condition) {
+		for _, e := range conditionEntries(from) {
+			if _, ok := to.Get(e.operator, e.key); !ok {
+				// The key was added or removed as a whole. Adding a key
+				// narrows the statement.
+				c.add(s, ElementCondition, op, e.String(), (op == Removed) == allow)
+				continue
+			}
+			other, _ := to.Get(e.operator, e.key)
+			if containsValue(conditionValues(other), e.value) {
+				continue
+			}
+			// Adding a value to a key widens the statement.
+			widens := (op == Added) != negated(e.operator)
+			c.add(s, ElementCondition, op, e.String(), widens == allow)
+		}
+	}
+	report(new, Added, new.Condition, old.Condition)
+	report(new, Removed, old.Condition, new.Condition)
+}
+
+// conditionEntry is a single value of a condition.
+type conditionEntry struct {
+	operator, key, value string
+}
+
+func (e conditionEntry) String() string {
+	return e.operator + " " + e.key + " " + e.value
+}
+
+// conditionEntries returns every value of a condition, sorted by operator and
+// key.
+func conditionEntries(c policy.Condition) []conditionEntry {
+	resp := []conditionEntry{}
+	for _, operator := range c.Operators() {
+		block := c.Block(operator)
+		for _, key := range block.Keys() {
+			for _, v := range conditionValues(block[key]) {
+				resp = append(resp, conditionEntry{operator: operator, key: key, value: v})
+			}
+		}
+	}
+	return resp
+}
+
+// conditionValues returns the values of a condition as strings.
+func conditionValues(v *policy.ConditionValue) []string {
+	resp := []string{}
+	if v == nil {
+		return resp
+	}
+	for _, e := range v.Elements() {
+		switch e.Kind {
+		case policy.ConditionElementBool:
+			resp = append(resp, strconv.FormatBool(e.Bool))
+		case policy.ConditionElementNumber:
+			resp = append(resp, e.Number.String())
+		case policy.ConditionElementIntrinsic:
+			resp = append(resp, e.Intrinsic.String())
+		default:
+			resp = append(resp, e.String)
+		}
+	}
+	sort.Strings(resp)
+	return resp
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// negated returns true for operators that match when the request value is
+// not one of the condition values, such as StringNotEquals.
+func negated(operator string) bool {
+	op, err := policy.ParseConditionOperator(operator)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(op.Name, "Not")
+}
@@ -0,0 +1,223 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/catalog"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func testCatalog() *catalog.Catalog {
+	actions := []*catalog.Action{}
+	for _, name := range []string{"GetObject", "GetObjectAcl", "ListBucket", "PutObject"} {
+		actions = append(actions, &catalog.Action{Name: name})
+	}
+	return catalog.New(&catalog.Service{Name: "s3", Actions: actions})
+}
+
+func mustUnmarshal(t *testing.T, in string) *policy.Policy {
+	t.Helper()
+	p, err := policy.Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return p
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		name      string
+		old       string
+		new       string
+		want      []string
+		broadened bool
+	}{
+		{
+			name: "Equal",
+			old:  `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": ["s3:GetObject", "s3:GetObjectAcl"], "Resource": "*"}}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject*", "Resource": "*"}]}`,
+		},
+		{
+			name: "WildcardAction",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/Action: added s3:ListBucket (broadens access)",
+				"/Statement/0/Action: added s3:PutObject (broadens access)",
+			},
+			broadened: true,
+		},
+		{
+			name: "NarrowedResource",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": ["arn:aws:s3:::bucket/logs/*", "arn:aws:s3:::bucket/data/*"]}]}`,
+			want: []string{
+				"/Statement/0/Resource: removed arn:aws:s3:::bucket/*",
+			},
+		},
+		{
+			name: "UnknownActionPattern",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "ec2:Describe*", "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["ec2:DescribeInstances", "ec2:*"], "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/Action: added ec2:* (broadens access)",
+			},
+			broadened: true,
+		},
+		{
+			name: "AllActions",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:*", "dynamodb:*"], "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/Action: added * (broadens access)",
+			},
+			broadened: true,
+		},
+		{
+			name: "Principals",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": {"AWS": ["111122223333", "444455556666"]}, "Action": "sts:AssumeRole"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": {"AWS": "111122223333", "Service": "ec2.amazonaws.com"}, "Action": "sts:AssumeRole"}]}`,
+			want: []string{
+				"/Statement/0/Principal: added Service:ec2.amazonaws.com (broadens access)",
+				"/Statement/0/Principal: removed AWS:444455556666",
+			},
+			broadened: true,
+		},
+		{
+			name: "Conditions",
+			old: `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*", "Condition": {
+				"StringEquals": {"aws:SourceVpc": "vpc-1", "aws:PrincipalOrgID": "o-1"},
+				"StringNotEquals": {"aws:PrincipalTag/team": ["a", "b"]}}}]}`,
+			new: `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*", "Condition": {
+				"StringEquals": {"aws:SourceVpc": ["vpc-1", "vpc-2"]},
+				"StringNotEquals": {"aws:PrincipalTag/team": ["a"]},
+				"Bool": {"aws:SecureTransport": true}}}]}`,
+			want: []string{
+				"/Statement/0/Condition: added Bool aws:SecureTransport true",
+				"/Statement/0/Condition: added StringEquals aws:SourceVpc vpc-2 (broadens access)",
+				"/Statement/0/Condition: removed StringEquals aws:PrincipalOrgID o-1 (broadens access)",
+				"/Statement/0/Condition: removed StringNotEquals aws:PrincipalTag/team b (broadens access)",
+			},
+			broadened: true,
+		},
+		{
+			name: "Deny",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Action": ["s3:GetObject", "s3:PutObject"], "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Action": ["s3:GetObject", "s3:ListBucket"], "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/Action: added s3:ListBucket",
+				"/Statement/0/Action: removed s3:PutObject (broadens access)",
+			},
+			broadened: true,
+		},
+		{
+			name: "NotAction",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "NotAction": ["s3:PutObject"], "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "NotAction": ["s3:PutObject", "s3:ListBucket"], "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/NotAction: added s3:ListBucket",
+			},
+		},
+		{
+			name: "Statements",
+			old: `{"Version": "2012-10-17", "Statement": [
+				{"Sid": "DenyDelete", "Effect": "Deny", "Action": "s3:PutObject", "Resource": "*"},
+				{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			new: `{"Version": "2012-10-17", "Statement": [
+				{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"},
+				{"Sid": "List", "Effect": "Allow", "Action": "s3:ListBucket", "Resource": "*"}]}`,
+			want: []string{
+				`/Statement/1: added allow statement "List" (broadens access)`,
+				`/Statement/0: removed deny statement "DenyDelete" (broadens access)`,
+			},
+			broadened: true,
+		},
+		{
+			name: "EffectChanged",
+			old:  `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}}`,
+			new:  `{"Version": "2012-10-17", "Statement": {"Effect": "Deny", "Action": "s3:GetObject", "Resource": "*"}}`,
+			want: []string{
+				"/Statement: added deny statement",
+				"/Statement: removed allow statement",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Compare(mustUnmarshal(t, tc.old), mustUnmarshal(t, tc.new), testCatalog())
+			if len(result.Changes) != len(tc.want) {
+				t.Fatalf("got %d changes '%v', want %d", len(result.Changes), result.Changes, len(tc.want))
+			}
+			for i, c := range result.Changes {
+				if c.String() != tc.want[i] {
+					t.Errorf("got '%s', want '%s'", c.String(), tc.want[i])
+				}
+			}
+			if result.Equal() != (len(tc.want) == 0) {
+				t.Errorf("got Equal() %t, want %t", result.Equal(), len(tc.want) == 0)
+			}
+			if result.Broadened() != tc.broadened {
+				t.Errorf("got Broadened() %t, want %t", result.Broadened(), tc.broadened)
+			}
+		})
+	}
+}
+
+func TestComparePartialCatalog(t *testing.T) {
+	cases := []struct {
+		name string
+		old  string
+		new  string
+		want []string
+	}{
+		{
+			name: "AllActions",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "ec2:*", "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/Action: added * (broadens access)",
+			},
+		},
+		{
+			name: "ServiceWildcard",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "ec2:Describe*", "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "ec2:*", "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/Action: added ec2:* (broadens access)",
+			},
+		},
+		{
+			name: "Narrowed",
+			old:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}`,
+			new:  `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "ec2:DescribeInstances", "Resource": "*"}]}`,
+			want: []string{
+				"/Statement/0/Action: removed *",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Compare(mustUnmarshal(t, tc.old), mustUnmarshal(t, tc.new), catalog.Default())
+			if len(result.Changes) != len(tc.want) {
+				t.Fatalf("got %d changes '%v', want %d", len(result.Changes), result.Changes, len(tc.want))
+			}
+			for i, c := range result.Changes {
+				if c.String() != tc.want[i] {
+					t.Errorf("got '%s', want '%s'", c.String(), tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompareNil(t *testing.T) {
+	p := mustUnmarshal(t, `{"Version": "2012-10-17", "Statement": {"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}}`)
+	result := Compare(nil, p, nil)
+	want := `/Statement: added allow statement "Read" (broadens access)`
+	if len(result.Changes) != 1 || result.Changes[0].String() != want {
+		t.Errorf("got '%v', want '%s'", result.Changes, want)
+	}
+	if !Compare(p, p, nil).Equal() {
+		t.Errorf("got changes comparing a policy with itself")
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Package eval evaluates requests against a [policy.Policy] using the AWS policy
+evaluation logic for a single policy: an explicit Deny in any matching
+statement overrides any Allow, a matching Allow statement allows the request,
+and a request that is not explicitly allowed is implicitly denied.
+
+See the [AWS policy evaluation logic] documentation for more details.
+
+[AWS policy evaluation logic]: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_evaluation-logic.html
+*/
+package eval
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/micahhausler/aws-iam-policy/arn"
+	"github.com/micahhausler/aws-iam-policy/condition"
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+const (
+	ErrorNilPolicy      = "policy is nil"
+	ErrorNilRequest     = "request is nil"
+	ErrorMissingAction  = "request has no action"
+	ErrorUnknownEffect  = "statement has an unknown effect"
+	ErrorEvaluatingStmt = "error evaluating statement"
+)
+
+// Decision is the outcome of evaluating a request against a policy.
+type Decision int
+
+const (
+	// ImplicitDeny means no statement allowed or denied the request.
+	ImplicitDeny Decision = iota
+	// Allow means at least one statement allowed the request, and none denied it.
+	Allow
+	// ExplicitDeny means at least one statement denied the request.
+	ExplicitDeny
+)
+
+// String returns the name of the decision.
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "Allow"
+	case ExplicitDeny:
+		return "ExplicitDeny"
+	default:
+		return "ImplicitDeny"
+	}
+}
+
+// Principal is the principal making a request. Kind is one of the
+// policy.PrincipalKind* constants other than PrincipalKindAll, and ID is the
+// identifier in the same form used in policies, such as an IAM role ARN or a
+// service name like "cloudtrail.amazonaws.com".
+type Principal struct {
+	Kind string
+	ID   string
+}
+
+// Request is the request context a policy is evaluated against.
+type Request struct {
+	// Principal is the principal making the request. It is only required
+	// when evaluating statements with a Principal or NotPrincipal element.
+	Principal *Principal
+	// Action is the action being performed, such as "s3:GetObject".
+	Action string
+	// Resource is the ARN of the resource being acted upon.
+	Resource string
+	// Context holds the values of condition keys present in the request.
+	// Keys are matched case-insensitively.
+	Context condition.Context
+}
+
+// StatementMatch identifies a statement that matched a request.
+type StatementMatch struct {
+	// Index is the position of the statement in the policy.
+	Index  int
+	Sid    string
+	Effect string
+}
+
+// Result is the result of evaluating a request against a policy.
+type Result struct {
+	Decision Decision
+	// Matched lists the statements that applied to the request, in policy
+	// order.
+	Matched []StatementMatch
+}
+
+// Evaluate evaluates a request against a policy.
+func Evaluate(p *policy.Policy, req *Request) (*Result, error) {
+	if p == nil {
+		return nil, errors.New(ErrorNilPolicy)
+	}
+	if req == nil {
+		return nil, errors.New(ErrorNilRequest)
+	}
+	if req.Action == "" {
+		return nil, errors.New(ErrorMissingAction)
+	}
+	result := &Result{Decision: ImplicitDeny}
+	if p.Statements == nil {
+		return result, nil
+	}
+	for i, statement := range p.Statements.Values() {
+		statement := statement
+		matched, err := MatchStatement(&statement, req)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", ErrorEvaluatingStmt, i, err)
+		}
+		if !matched {
+			continue
+		}
+		result.Matched = append(result.Matched, StatementMatch{
+			Index:  i,
+			Sid:    statement.Sid,
+			Effect: statement.Effect,
+		})
+		switch statement.Effect {
+		case policy.EffectDeny:
+			result.Decision = ExplicitDeny
+		case policy.EffectAllow:
+			if result.Decision != ExplicitDeny {
+				result.Decision = Allow
+			}
+		default:
+			return nil, fmt.Errorf("%s %d: %s %q", ErrorEvaluatingStmt, i, ErrorUnknownEffect, statement.Effect)
+		}
+	}
+	return result, nil
+}
+
+// MatchStatement returns true if the statement applies to the request,
+// regardless of its Effect.
+func MatchStatement(s *policy.Statement, req *Request) (bool, error) {
+	if !matchActions(s, req.Action) {
+		return false, nil
+	}
+	if !matchResources(s, req.Resource) {
+		return false, nil
+	}
+	if !matchPrincipals(s, req.Principal) {
+		return false, nil
+	}
+	return condition.EvaluateBlock(s.Condition, req.Context)
+}
+
+func matchActions(s *policy.Statement, action string) bool {
+	if s.NotAction != nil {
+		return !anyMatch(s.NotAction, action, wildcard.MatchFold)
+	}
+	if s.Action != nil {
+		return anyMatch(s.Action, action, wildcard.MatchFold)
+	}
+	return false
+}
+
+func matchResources(s *policy.Statement, resource string) bool {
+	if s.NotResource != nil {
+		return !anyMatch(s.NotResource, resource, arn.Match)
+	}
+	if s.Resource != nil {
+		return anyMatch(s.Resource, resource, arn.Match)
+	}
+	// Resource-based policies such as role trust policies omit the Resource
+	// element, and apply to the resource they are attached to.
+	return true
+}
+
+func anyMatch(patterns *policy.StringOrSlice, value string, match func(string, string) bool) bool {
+	for _, pattern := range patterns.Values() {
+		if match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
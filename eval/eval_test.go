@@ -0,0 +1,363 @@
+package eval
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+const bucketPolicy = `{
+	"Version": "2012-10-17",
+	"Statement": [
+		{
+			"Sid": "AllowRead",
+			"Effect": "Allow",
+			"Principal": {"AWS": "111122223333"},
+			"Action": "s3:Get*",
+			"Resource": "arn:aws:s3:::examplebucket/*"
+		},
+		{
+			"Sid": "AllowUploadWithACL",
+			"Effect": "Allow",
+			"Principal": {"AWS": ["arn:aws:iam::444455556666:root"]},
+			"Action": "s3:PutObject",
+			"Resource": "arn:aws:s3:::examplebucket/*",
+			"Condition": {
+				"StringEquals": {"s3:x-amz-acl": "bucket-owner-full-control"}
+			}
+		},
+		{
+			"Sid": "DenySecrets",
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:*",
+			"Resource": "arn:aws:s3:::examplebucket/secret/*"
+		},
+		{
+			"Sid": "DenyInsecureTransport",
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:*",
+			"Resource": "arn:aws:s3:::examplebucket/*",
+			"Condition": {
+				"Bool": {"aws:SecureTransport": false}
+			}
+		}
+	]
+}`
+
+const identityPolicy = `{
+	"Version": "2012-10-17",
+	"Statement": [
+		{
+			"Effect": "Allow",
+			"NotAction": "iam:*",
+			"Resource": "*"
+		},
+		{
+			"Effect": "Deny",
+			"Action": "*",
+			"NotResource": "arn:aws:ec2:*:*:instance/*",
+			"Condition": {
+				"StringNotEqualsIfExists": {"aws:RequestedRegion": ["us-east-1", "us-west-2"]}
+			}
+		}
+	]
+}`
+
+func mustPolicy(t *testing.T, doc string) *policy.Policy {
+	t.Helper()
+	p := &policy.Policy{}
+	if err := json.Unmarshal([]byte(doc), p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return p
+}
+
+func TestEvaluate(t *testing.T) {
+	cases := []struct {
+		name        string
+		policy      string
+		req         *Request
+		want        Decision
+		wantMatched []string
+	}{
+		{
+			name:   "AllowByAccountID",
+			policy: bucketPolicy,
+			req: &Request{
+				Principal: &Principal{Kind: policy.PrincipalKindAWS, ID: "arn:aws:iam::111122223333:role/reader"},
+				Action:    "s3:GetObject",
+				Resource:  "arn:aws:s3:::examplebucket/logs/1.txt",
+				Context:   map[string][]string{"aws:SecureTransport": {"true"}},
+			},
+			want:        Allow,
+			wantMatched: []string{"AllowRead"},
+		},
+		{
+			name:   "ActionIsCaseInsensitive",
+			policy: bucketPolicy,
+			req: &Request{
+				Principal: &Principal{Kind: policy.PrincipalKindAWS, ID: "111122223333"},
+				Action:    "S3:getobject",
+				Resource:  "arn:aws:s3:::examplebucket/logs/1.txt",
+			},
+			want:        Allow,
+			wantMatched: []string{"AllowRead"},
+		},
+		{
+			name:   "ImplicitDenyOtherAccount",
+			policy: bucketPolicy,
+			req: &Request{
+				Principal: &Principal{Kind: policy.PrincipalKindAWS, ID: "arn:aws:iam::999999999999:role/reader"},
+				Action:    "s3:GetObject",
+				Resource:  "arn:aws:s3:::examplebucket/logs/1.txt",
+			},
+			want: ImplicitDeny,
+		},
+		{
+			name:   "ExplicitDenyWins",
+			policy: bucketPolicy,
+			req: &Request{
+				Principal: &Principal{Kind: policy.PrincipalKindAWS, ID: "111122223333"},
+				Action:    "s3:GetObject",
+				Resource:  "arn:aws:s3:::examplebucket/secret/key",
+			},
+			want:        ExplicitDeny,
+			wantMatched: []string{"AllowRead", "DenySecrets"},
+		},
+		{
+			name:   "ConditionSatisfied",
+			policy: bucketPolicy,
+			req: &Request{
+				Principal: &Principal{Kind: policy.PrincipalKindAWS, ID: "arn:aws:iam::444455556666:user/Bob"},
+				Action:    "s3:PutObject",
+				Resource:  "arn:aws:s3:::examplebucket/upload",
+				Context:   map[string][]string{"s3:x-amz-acl": {"bucket-owner-full-control"}},
+			},
+			want:        Allow,
+			wantMatched: []string{"AllowUploadWithACL"},
+		},
+		{
+			name:   "ConditionNotSatisfied",
+			policy: bucketPolicy,
+			req: &Request{
+				Principal: &Principal{Kind: policy.PrincipalKindAWS, ID: "arn:aws:iam::444455556666:user/Bob"},
+				Action:    "s3:PutObject",
+				Resource:  "arn:aws:s3:::examplebucket/upload",
+				Context:   map[string][]string{"s3:x-amz-acl": {"public-read"}},
+			},
+			want: ImplicitDeny,
+		},
+		{
+			name:   "ConditionKeyIsCaseInsensitive",
+			policy: bucketPolicy,
+			req: &Request{
+				Principal: &Principal{Kind: policy.PrincipalKindAWS, ID: "111122223333"},
+				Action:    "s3:GetObject",
+				Resource:  "arn:aws:s3:::examplebucket/logs/1.txt",
+				Context:   map[string][]string{"AWS:SECURETRANSPORT": {"false"}},
+			},
+			want:        ExplicitDeny,
+			wantMatched: []string{"AllowRead", "DenyInsecureTransport"},
+		},
+		{
+			name:   "NotActionAllows",
+			policy: identityPolicy,
+			req: &Request{
+				Action:   "ec2:RunInstances",
+				Resource: "arn:aws:ec2:us-east-1:111122223333:instance/i-1234567890",
+				Context:  map[string][]string{"aws:RequestedRegion": {"us-east-1"}},
+			},
+			want:        Allow,
+			wantMatched: []string{""},
+		},
+		{
+			name:   "NotActionExcludes",
+			policy: identityPolicy,
+			req: &Request{
+				Action:   "iam:CreateUser",
+				Resource: "arn:aws:iam::111122223333:user/Bob",
+				Context:  map[string][]string{"aws:RequestedRegion": {"us-west-2"}},
+			},
+			want: ImplicitDeny,
+		},
+		{
+			name:   "NotResourceDenies",
+			policy: identityPolicy,
+			req: &Request{
+				Action:   "s3:ListBucket",
+				Resource: "arn:aws:s3:::examplebucket",
+				Context:  map[string][]string{"aws:RequestedRegion": {"eu-west-1"}},
+			},
+			want:        ExplicitDeny,
+			wantMatched: []string{"", ""},
+		},
+		{
+			name:   "NegatedConditionMissingKey",
+			policy: identityPolicy,
+			req: &Request{
+				Action:   "s3:ListBucket",
+				Resource: "arn:aws:s3:::examplebucket",
+			},
+			want:        ExplicitDeny,
+			wantMatched: []string{"", ""},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Evaluate(mustPolicy(t, tc.policy), tc.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Decision != tc.want {
+				t.Errorf("got '%s', want '%s'", got.Decision, tc.want)
+			}
+			if len(got.Matched) != len(tc.wantMatched) {
+				t.Fatalf("got '%d' matched statements, want '%d'", len(got.Matched), len(tc.wantMatched))
+			}
+			for i, m := range got.Matched {
+				if m.Sid != tc.wantMatched[i] {
+					t.Errorf("got '%s', want '%s'", m.Sid, tc.wantMatched[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluatePrincipal(t *testing.T) {
+	cases := []struct {
+		name      string
+		principal *policy.Principal
+		not       bool
+		req       *Principal
+		want      Decision
+	}{
+		{
+			name:      "Global",
+			principal: policy.NewGlobalPrincipal(),
+			req:       &Principal{Kind: policy.PrincipalKindService, ID: "cloudtrail.amazonaws.com"},
+			want:      Allow,
+		},
+		{
+			name:      "Service",
+			principal: policy.NewServicePrincipal("cloudtrail.amazonaws.com"),
+			req:       &Principal{Kind: policy.PrincipalKindService, ID: "cloudtrail.amazonaws.com"},
+			want:      Allow,
+		},
+		{
+			name:      "ServiceMismatch",
+			principal: policy.NewServicePrincipal("cloudtrail.amazonaws.com"),
+			req:       &Principal{Kind: policy.PrincipalKindService, ID: "config.amazonaws.com"},
+			want:      ImplicitDeny,
+		},
+		{
+			name:      "KindMismatch",
+			principal: policy.NewFederatedPrincipal("cognito-identity.amazonaws.com"),
+			req:       &Principal{Kind: policy.PrincipalKindService, ID: "cognito-identity.amazonaws.com"},
+			want:      ImplicitDeny,
+		},
+		{
+			name:      "RootARN",
+			principal: policy.NewAWSPrincipal("arn:aws:iam::111122223333:root"),
+			req:       &Principal{Kind: policy.PrincipalKindAWS, ID: "arn:aws:iam::111122223333:user/Alice"},
+			want:      Allow,
+		},
+		{
+			name:      "NotPrincipalExcluded",
+			principal: policy.NewAWSPrincipal("arn:aws:iam::111122223333:user/Alice"),
+			not:       true,
+			req:       &Principal{Kind: policy.PrincipalKindAWS, ID: "arn:aws:iam::111122223333:user/Alice"},
+			want:      ImplicitDeny,
+		},
+		{
+			name:      "NotPrincipalIncluded",
+			principal: policy.NewAWSPrincipal("arn:aws:iam::111122223333:user/Alice"),
+			not:       true,
+			req:       &Principal{Kind: policy.PrincipalKindAWS, ID: "arn:aws:iam::111122223333:user/Bob"},
+			want:      Allow,
+		},
+		{
+			name:      "MissingRequestPrincipal",
+			principal: policy.NewGlobalPrincipal(),
+			want:      ImplicitDeny,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			statement := policy.Statement{
+				Effect: policy.EffectAllow,
+				Action: policy.NewStringOrSlice(true, "sts:AssumeRole"),
+			}
+			if tc.not {
+				statement.NotPrincipal = tc.principal
+			} else {
+				statement.Principal = tc.principal
+			}
+			p := &policy.Policy{
+				Version:    policy.VersionLatest,
+				Statements: policy.NewSingularStatementOrSlice(statement),
+			}
+			got, err := Evaluate(p, &Request{Principal: tc.req, Action: "sts:AssumeRole"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Decision != tc.want {
+				t.Errorf("got '%s', want '%s'", got.Decision, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	p := mustPolicy(t, `{
+		"Version": "2012-10-17",
+		"Statement": {
+			"Effect": "Allow",
+			"Action": "s3:GetObject",
+			"Resource": "*",
+			"Condition": {"StringEqualz": {"aws:PrincipalTag/team": "a"}}
+		}
+	}`)
+	cases := []struct {
+		name    string
+		policy  *policy.Policy
+		req     *Request
+		wantErr string
+	}{
+		{
+			name:    "NilPolicy",
+			req:     &Request{Action: "s3:GetObject"},
+			wantErr: ErrorNilPolicy,
+		},
+		{
+			name:    "NilRequest",
+			policy:  p,
+			wantErr: ErrorNilRequest,
+		},
+		{
+			name:    "MissingAction",
+			policy:  p,
+			req:     &Request{},
+			wantErr: ErrorMissingAction,
+		},
+		{
+			name:    "UnsupportedOperator",
+			policy:  p,
+			req:     &Request{Action: "s3:GetObject", Resource: "arn:aws:s3:::examplebucket/key"},
+			wantErr: `error evaluating statement 0: StringEqualz aws:PrincipalTag/team: unknown condition operator "StringEqualz"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Evaluate(tc.policy, tc.req)
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if err.Error() != tc.wantErr {
+				t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package eval
+
+import (
+	"github.com/micahhausler/aws-iam-policy/arn"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func matchPrincipals(s *policy.Statement, principal *Principal) bool {
+	if s.Principal == nil && s.NotPrincipal == nil {
+		return true
+	}
+	if principal == nil {
+		return false
+	}
+	if s.NotPrincipal != nil {
+		return !matchPrincipal(s.NotPrincipal, principal)
+	}
+	return matchPrincipal(s.Principal, principal)
+}
+
+// matchPrincipal returns true if the policy principal includes the request
+// principal.
+func matchPrincipal(p *policy.Principal, principal *Principal) bool {
+	var values *policy.StringOrSlice
+	for _, kind := range p.Kinds() {
+		switch kind {
+		case policy.PrincipalKindAll:
+			return true
+		case policy.PrincipalKindAWS:
+			values = p.AWS()
+		case policy.PrincipalKindCanonical:
+			values = p.CanonicalUser()
+		case policy.PrincipalKindFederated:
+			values = p.Federated()
+		case policy.PrincipalKindService:
+			values = p.Service()
+		}
+		if kind != principal.Kind {
+			continue
+		}
+		for _, value := range values.Values() {
+			if value == policy.PrincipalAll || value == principal.ID {
+				return true
+			}
+			if kind == policy.PrincipalKindAWS && matchAccount(value, principal.ID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAccount returns true if the policy value is an account, either as an
+// account ID or as the account root ARN, that contains the request principal.
+func matchAccount(value, id string) bool {
+	account := arn.AccountID(id)
+	if account == "" {
+		return false
+	}
+	if value == account {
+		return true
+	}
+	a, err := arn.Parse(value)
+	return err == nil && a.IsAccountRoot() && a.AccountID == account
+}
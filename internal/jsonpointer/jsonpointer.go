@@ -0,0 +1,50 @@
+// Package jsonpointer builds JSON pointers (RFC 6901), which are used to
+// report the location of a problem within a policy document.
+package jsonpointer
+
+import "strings"
+
+var escaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Append appends reference tokens to a JSON pointer, escaping them as needed.
+func Append(base string, tokens ...string) string {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(escaper.Replace(token))
+	}
+	return b.String()
+}
+
+// Less reports whether pointer a sorts before pointer b. Pointers are compared
+// token by token, and array indexes are compared as numbers, so
+// "/Statement/2" sorts before "/Statement/10".
+func Less(a, b string) bool {
+	at := strings.Split(a, "/")
+	bt := strings.Split(b, "/")
+	for i := 0; i < len(at) && i < len(bt); i++ {
+		if at[i] == bt[i] {
+			continue
+		}
+		if isIndex(at[i]) && isIndex(bt[i]) && len(at[i]) != len(bt[i]) {
+			return len(at[i]) < len(bt[i])
+		}
+		return at[i] < bt[i]
+	}
+	return len(at) < len(bt)
+}
+
+// isIndex returns true if a reference token is an array index, which RFC 6901
+// writes as a decimal number without leading zeros.
+func isIndex(token string) bool {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
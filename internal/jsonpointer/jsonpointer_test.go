@@ -0,0 +1,48 @@
+package jsonpointer
+
+import "testing"
+
+func TestAppend(t *testing.T) {
+	cases := []struct {
+		name   string
+		base   string
+		tokens []string
+		want   string
+	}{
+		{name: "Root", base: "", tokens: []string{"Statement"}, want: "/Statement"},
+		{name: "Nested", base: "/Statement", tokens: []string{"0", "Action"}, want: "/Statement/0/Action"},
+		{name: "Escaped", base: "/Statement/Condition", tokens: []string{"StringEquals", "aws:PrincipalTag/team~1"}, want: "/Statement/Condition/StringEquals/aws:PrincipalTag~1team~01"},
+		{name: "NoTokens", base: "/Statement", want: "/Statement"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Append(tc.base, tc.tokens...); got != tc.want {
+				t.Errorf("got '%s', want '%s'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLess(t *testing.T) {
+	cases := []struct {
+		name string
+		a    string
+		b    string
+		want bool
+	}{
+		{name: "Index", a: "/Statement/2", b: "/Statement/10", want: true},
+		{name: "IndexReversed", a: "/Statement/10", b: "/Statement/2", want: false},
+		{name: "SameLengthIndex", a: "/Statement/12", b: "/Statement/3/Action", want: false},
+		{name: "Prefix", a: "/Statement/1", b: "/Statement/1/Action", want: true},
+		{name: "Names", a: "/Statement/1/Action", b: "/Statement/1/Resource", want: true},
+		{name: "LeadingZero", a: "/Condition/10", b: "/Condition/09", want: false},
+		{name: "Equal", a: "/Statement", b: "/Statement", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Less(tc.a, tc.b); got != tc.want {
+				t.Errorf("got %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
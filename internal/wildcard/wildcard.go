@@ -0,0 +1,112 @@
+// Package wildcard implements the glob matching used by IAM policy elements,
+// where '*' matches any sequence of characters (including an empty one) and
+// '?' matches exactly one character.
+package wildcard
+
+import "strings"
+
+// Match reports whether value matches pattern. The comparison is case
+// sensitive.
+func Match(pattern, value string) bool {
+	return match(pattern, value, false)
+}
+
+// MatchFold reports whether value matches pattern, ignoring case.
+func MatchFold(pattern, value string) bool {
+	return match(pattern, value, true)
+}
+
+// HasWildcard returns true if the pattern contains a '*' or '?'.
+func HasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?")
+}
+
+// Subsumes reports whether every value matched by other is also matched by
+// pattern, treating both as patterns. It is conservative: a '*' in other can
+// only be covered by a '*' in pattern, and a '?' in other by a '?' or '*' in
+// pattern. The comparison is case sensitive.
+func Subsumes(pattern, other string) bool {
+	return subsumes(pattern, other, false)
+}
+
+// SubsumesFold is like Subsumes, but ignores case.
+func SubsumesFold(pattern, other string) bool {
+	return subsumes(pattern, other, true)
+}
+
+func subsumes(pattern, other string, fold bool) bool {
+	p := []rune(pattern)
+	o := []rune(other)
+	// memo[i][j] caches whether p[i:] subsumes o[j:]: 0 unknown, 1 true,
+	// 2 false.
+	memo := make([][]byte, len(p)+1)
+	for i := range memo {
+		memo[i] = make([]byte, len(o)+1)
+	}
+	var rec func(i, j int) bool
+	rec = func(i, j int) bool {
+		if memo[i][j] != 0 {
+			return memo[i][j] == 1
+		}
+		var ok bool
+		switch {
+		case i == len(p):
+			ok = j == len(o)
+		case p[i] == '*':
+			ok = rec(i+1, j) || (j < len(o) && rec(i, j+1))
+		case j == len(o) || o[j] == '*':
+			ok = false
+		case p[i] == '?':
+			ok = rec(i+1, j+1)
+		default:
+			ok = o[j] != '?' && runeEqual(p[i], o[j], fold) && rec(i+1, j+1)
+		}
+		memo[i][j] = 2
+		if ok {
+			memo[i][j] = 1
+		}
+		return ok
+	}
+	return rec(0, 0)
+}
+
+func match(pattern, value string, fold bool) bool {
+	p := []rune(pattern)
+	v := []rune(value)
+	pi, vi := 0, 0
+	// star and mark record the last '*' seen in the pattern and the position in
+	// the value it was tried against, so we can backtrack when a later part of
+	// the pattern fails to match.
+	star, mark := -1, 0
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && p[pi] == '*':
+			star = pi
+			mark = vi
+			pi++
+		case pi < len(p) && (p[pi] == '?' || runeEqual(p[pi], v[vi], fold)):
+			pi++
+			vi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			vi = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+func runeEqual(a, b rune, fold bool) bool {
+	if a == b {
+		return true
+	}
+	if !fold {
+		return false
+	}
+	return strings.EqualFold(string(a), string(b))
+}
@@ -0,0 +1,67 @@
+package wildcard
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		pattern  string
+		value    string
+		want     bool
+		wantFold bool
+	}{
+		{name: "Exact", pattern: "s3:GetObject", value: "s3:GetObject", want: true, wantFold: true},
+		{name: "Case", pattern: "s3:getobject", value: "s3:GetObject", want: false, wantFold: true},
+		{name: "Star", pattern: "*", value: "anything", want: true, wantFold: true},
+		{name: "StarEmpty", pattern: "*", value: "", want: true, wantFold: true},
+		{name: "Prefix", pattern: "s3:Get*", value: "s3:GetObject", want: true, wantFold: true},
+		{name: "PrefixMismatch", pattern: "s3:Put*", value: "s3:GetObject", want: false, wantFold: false},
+		{name: "Middle", pattern: "arn:aws:s3:::bucket/*/logs", value: "arn:aws:s3:::bucket/a/b/logs", want: true, wantFold: true},
+		{name: "Question", pattern: "s3:GetObjec?", value: "s3:GetObject", want: true, wantFold: true},
+		{name: "QuestionTooShort", pattern: "s3:GetObject?", value: "s3:GetObject", want: false, wantFold: false},
+		{name: "Backtrack", pattern: "*Object*Tagging", value: "s3:GetObjectVersionTagging", want: true, wantFold: true},
+		{name: "TrailingLiteral", pattern: "*abc", value: "abcab", want: false, wantFold: false},
+		{name: "EmptyPattern", pattern: "", value: "a", want: false, wantFold: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Match(tc.pattern, tc.value); got != tc.want {
+				t.Errorf("Match(%q, %q) got '%t', want '%t'", tc.pattern, tc.value, got, tc.want)
+			}
+			if got := MatchFold(tc.pattern, tc.value); got != tc.wantFold {
+				t.Errorf("MatchFold(%q, %q) got '%t', want '%t'", tc.pattern, tc.value, got, tc.wantFold)
+			}
+		})
+	}
+}
+
+func TestSubsumes(t *testing.T) {
+	cases := []struct {
+		name     string
+		pattern  string
+		other    string
+		want     bool
+		wantFold bool
+	}{
+		{name: "Literal", pattern: "s3:Get*", other: "s3:GetObject", want: true, wantFold: true},
+		{name: "Equal", pattern: "s3:Get*", other: "s3:Get*", want: true, wantFold: true},
+		{name: "Narrower", pattern: "s3:*", other: "s3:Get*", want: true, wantFold: true},
+		{name: "Wider", pattern: "s3:Get*", other: "s3:*", want: false, wantFold: false},
+		{name: "Middle", pattern: "s3:*Object", other: "s3:Get*Object", want: true, wantFold: true},
+		{name: "QuestionCoversQuestion", pattern: "s3:Ge??", other: "s3:Get?", want: true, wantFold: true},
+		{name: "QuestionNotStar", pattern: "s3:Get?", other: "s3:Get*", want: false, wantFold: false},
+		{name: "LiteralNotQuestion", pattern: "s3:GetA", other: "s3:Get?", want: false, wantFold: false},
+		{name: "Case", pattern: "S3:get*", other: "s3:GetObject*", want: false, wantFold: true},
+		{name: "Empty", pattern: "", other: "", want: true, wantFold: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Subsumes(tc.pattern, tc.other); got != tc.want {
+				t.Errorf("Subsumes(%q, %q) got '%t', want '%t'", tc.pattern, tc.other, got, tc.want)
+			}
+			if got := SubsumesFold(tc.pattern, tc.other); got != tc.wantFold {
+				t.Errorf("SubsumesFold(%q, %q) got '%t', want '%t'", tc.pattern, tc.other, got, tc.wantFold)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package yamljson converts between YAML and JSON documents while keeping the
+// order of mapping keys, so a policy written in one format reads the same in
+// the other.
+package yamljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ErrorEmptyDocument     = "YAML document is empty"
+	ErrorUnsupportedNode   = "unsupported YAML node"
+	ErrorNonScalarKey      = "YAML mapping keys must be scalars"
+	ErrorUnresolvedAlias   = "YAML alias could not be resolved"
+	ErrorInvalidYAMLNumber = "invalid YAML number"
+)
+
+// ToJSON converts a YAML document to JSON.
+func ToJSON(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, errors.New(ErrorEmptyDocument)
+	}
+	return NodeToJSON(doc.Content[0])
+}
+
+// NodeToJSON converts a YAML node to JSON.
+func NodeToJSON(n *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeNode(&buf, n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeNode(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return errors.New(ErrorEmptyDocument)
+		}
+		return writeNode(buf, n.Content[0])
+	case yaml.AliasNode:
+		if n.Alias == nil {
+			return errors.New(ErrorUnresolvedAlias)
+		}
+		return writeNode(buf, n.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return fmt.Errorf("%s (line %d)", ErrorNonScalarKey, key.Line)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, key.Value)
+			buf.WriteByte(':')
+			if err := writeNode(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.ScalarNode:
+		return writeScalar(buf, n)
+	default:
+		return fmt.Errorf("%s (line %d)", ErrorUnsupportedNode, n.Line)
+	}
+}
+
+func writeScalar(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.ShortTag() {
+	case "!!null":
+		buf.WriteString("null")
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return err
+		}
+		buf.WriteString(strconv.FormatBool(b))
+	case "!!int", "!!float":
+		// Numbers that are already valid JSON are copied as written, so
+		// that "1.0" stays "1.0".
+		if json.Valid([]byte(n.Value)) {
+			buf.WriteString(n.Value)
+			return nil
+		}
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return fmt.Errorf("%s %q (line %d)", ErrorInvalidYAMLNumber, n.Value, n.Line)
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	default:
+		writeString(buf, n.Value)
+	}
+	return nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	// Encoding a string cannot fail.
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1)
+}
+
+// FromJSON converts a JSON document to YAML in block style, indented with
+// two spaces.
+func FromJSON(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	resetStyle(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resetStyle removes the flow and quoting styles that JSON input gives a YAML
+// node, so it is written in block style with quotes only where needed.
+func resetStyle(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && strings.ContainsRune(n.Value, '\n') {
+		n.Style = yaml.LiteralStyle
+	}
+	for _, c := range n.Content {
+		resetStyle(c)
+	}
+}
@@ -0,0 +1,94 @@
+package yamljson
+
+import "testing"
+
+func TestToJSON(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "KeyOrder",
+			in: `Version: "2012-10-17"
+Statement:
+  - Effect: Allow
+    Action: [s3:GetObject, s3:PutObject]
+    Resource: "*"
+`,
+			want: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:PutObject"],"Resource":"*"}]}`,
+		},
+		{
+			name: "Scalars",
+			in:   "a: 1.0\nb: true\nc: null\nd: '10'\ne: 0x10\nf: <a&b>\n",
+			want: `{"a":1.0,"b":true,"c":null,"d":"10","e":16,"f":"<a&b>"}`,
+		},
+		{
+			name: "Alias",
+			in:   "a: &x [1, 2]\nb: *x\n",
+			want: `{"a":[1,2],"b":[1,2]}`,
+		},
+		{
+			name:    "NonScalarKey",
+			in:      "? [a]\n: b\n",
+			wantErr: "YAML mapping keys must be scalars (line 1)",
+		},
+		{
+			name:    "Empty",
+			in:      "",
+			wantErr: ErrorEmptyDocument,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ToJSON([]byte(tc.in))
+			if err != nil {
+				if tc.wantErr == "" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err.Error() != tc.wantErr {
+					t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if tc.wantErr != "" {
+				t.Fatalf("expected error, got nil")
+			}
+			if string(got) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	in := `{"Version":"2012-10-17","Statement":[{"Sid":"123","Effect":"Allow","Action":["s3:GetObject"],"Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"true"},"NumericLessThan":{"s3:max-keys":10}}}]}`
+	want := `Version: "2012-10-17"
+Statement:
+  - Sid: "123"
+    Effect: Allow
+    Action:
+      - s3:GetObject
+    Resource: '*'
+    Condition:
+      Bool:
+        aws:SecureTransport: "true"
+      NumericLessThan:
+        s3:max-keys: 10
+`
+	got, err := FromJSON([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got '%s', want '%s'", string(got), want)
+	}
+	back, err := ToJSON(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(back) != in {
+		t.Errorf("got '%s', want '%s'", string(back), in)
+	}
+}
@@ -0,0 +1,184 @@
+package lint
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// IDs of the checks registered by default.
+const (
+	CheckAllowWithNotAction            = "ALLOW_WITH_NOT_ACTION"
+	CheckAllowWithNotResource          = "ALLOW_WITH_NOT_RESOURCE"
+	CheckPrincipalStarWithoutCondition = "PRINCIPAL_STAR_WITHOUT_CONDITION"
+	CheckPassRoleWithStarInResource    = "PASS_ROLE_WITH_STAR_IN_RESOURCE"
+	CheckRedundantAction               = "REDUNDANT_ACTION"
+)
+
+func init() {
+	for _, c := range []*Check{
+		{
+			ID:          CheckAllowWithNotAction,
+			Severity:    SeveritySecurityWarning,
+			Description: "Allow statements with NotAction grant every action not listed, including actions added in the future.",
+			Run:         allowWithNotAction,
+		},
+		{
+			ID:          CheckAllowWithNotResource,
+			Severity:    SeveritySecurityWarning,
+			Description: "Allow statements with NotResource grant access to every resource not listed, including resources created in the future.",
+			Run:         allowWithNotResource,
+		},
+		{
+			ID:          CheckPrincipalStarWithoutCondition,
+			Severity:    SeveritySecurityWarning,
+			Description: "Allow statements with a \"*\" principal and no conditions grant access to anyone, including anonymous users.",
+			Run:         principalStarWithoutCondition,
+		},
+		{
+			ID:          CheckPassRoleWithStarInResource,
+			Severity:    SeveritySecurityWarning,
+			Description: "Allowing iam:PassRole on all resources lets a principal pass any role, including more privileged roles, to a service.",
+			Run:         passRoleWithStarInResource,
+		},
+		{
+			ID:          CheckRedundantAction,
+			Severity:    SeveritySuggestion,
+			Description: "Actions that are already matched by another action in the same statement can be removed.",
+			Run:         redundantAction,
+		},
+	} {
+		Register(c)
+	}
+}
+
+func allowWithNotAction(p *policy.Policy) []*Finding {
+	resp := []*Finding{}
+	ForEachStatement(p, func(path string, s *policy.Statement) {
+		if s.Effect == policy.EffectAllow && s.NotAction != nil {
+			resp = append(resp, &Finding{
+				Path:    jsonpointer.Append(path, "NotAction"),
+				Message: "using NotAction with Allow grants every action except the ones listed; list the allowed actions with Action instead",
+			})
+		}
+	})
+	return resp
+}
+
+func allowWithNotResource(p *policy.Policy) []*Finding {
+	resp := []*Finding{}
+	ForEachStatement(p, func(path string, s *policy.Statement) {
+		if s.Effect == policy.EffectAllow && s.NotResource != nil {
+			resp = append(resp, &Finding{
+				Path:    jsonpointer.Append(path, "NotResource"),
+				Message: "using NotResource with Allow grants access to every resource except the ones listed; list the allowed resources with Resource instead",
+			})
+		}
+	})
+	return resp
+}
+
+func principalStarWithoutCondition(p *policy.Policy) []*Finding {
+	resp := []*Finding{}
+	ForEachStatement(p, func(path string, s *policy.Statement) {
+		if s.Effect != policy.EffectAllow || s.Principal == nil || len(s.Condition) > 0 {
+			return
+		}
+		const message = "the principal \"*\" grants access to anyone; add a condition to limit access, such as aws:PrincipalOrgID or aws:SourceAccount"
+		for _, kind := range s.Principal.Kinds() {
+			switch kind {
+			case policy.PrincipalKindAll:
+				resp = append(resp, &Finding{Path: jsonpointer.Append(path, "Principal"), Message: message})
+			case policy.PrincipalKindAWS:
+				aws := s.Principal.AWS()
+				for i, value := range aws.Values() {
+					if value != policy.PrincipalAll {
+						continue
+					}
+					resp = append(resp, &Finding{
+						Path:    valuePointer(jsonpointer.Append(path, "Principal", policy.PrincipalKindAWS), aws, i),
+						Message: message,
+					})
+				}
+			}
+		}
+	})
+	return resp
+}
+
+func passRoleWithStarInResource(p *policy.Policy) []*Finding {
+	resp := []*Finding{}
+	ForEachStatement(p, func(path string, s *policy.Statement) {
+		if s.Effect != policy.EffectAllow || s.Action == nil || s.Resource == nil {
+			return
+		}
+		passRole := false
+		for _, action := range s.Action.Values() {
+			if wildcard.MatchFold(action, "iam:PassRole") {
+				passRole = true
+				break
+			}
+		}
+		if !passRole {
+			return
+		}
+		for i, resource := range s.Resource.Values() {
+			if resource != "*" {
+				continue
+			}
+			resp = append(resp, &Finding{
+				Path:    valuePointer(jsonpointer.Append(path, "Resource"), s.Resource, i),
+				Message: "iam:PassRole is allowed on all resources; limit the Resource to the ARNs of the roles that can be passed",
+			})
+		}
+	})
+	return resp
+}
+
+func redundantAction(p *policy.Policy) []*Finding {
+	resp := []*Finding{}
+	ForEachStatement(p, func(path string, s *policy.Statement) {
+		for _, elem := range []struct {
+			name    string
+			actions *policy.StringOrSlice
+		}{
+			{"Action", s.Action},
+			{"NotAction", s.NotAction},
+		} {
+			if elem.actions == nil {
+				continue
+			}
+			values := elem.actions.Values()
+			for i, action := range values {
+				for j, other := range values {
+					if i == j || !wildcard.SubsumesFold(other, action) {
+						continue
+					}
+					// Of two equivalent actions, only report the later one.
+					if j > i && wildcard.SubsumesFold(action, other) {
+						continue
+					}
+					resp = append(resp, &Finding{
+						Path:    valuePointer(jsonpointer.Append(path, elem.name), elem.actions, i),
+						Message: fmt.Sprintf("%q is already matched by %q", action, other),
+					})
+					break
+				}
+			}
+		}
+	})
+	return resp
+}
+
+// valuePointer returns the pointer of the value at index i of a
+// StringOrSlice. A singular value is not wrapped in an array, so its pointer
+// has no index.
+func valuePointer(base string, s *policy.StringOrSlice, i int) string {
+	if s.IsSingular() {
+		return base
+	}
+	return jsonpointer.Append(base, strconv.Itoa(i))
+}
@@ -0,0 +1,103 @@
+package lint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func TestChecks(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			name: "Clean",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": [
+					{"Effect": "Allow", "Action": ["s3:GetObject", "s3:PutObject"], "Resource": "arn:aws:s3:::examplebucket/*"},
+					{"Effect": "Deny", "NotAction": "iam:*", "NotResource": "*"},
+					{"Effect": "Allow", "Action": "iam:PassRole", "Resource": "arn:aws:iam::111122223333:role/app"}
+				]
+			}`,
+		},
+		{
+			name: "NotActionAndNotResource",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": [{"Effect": "Allow", "NotAction": "iam:*", "NotResource": "arn:aws:s3:::secret/*"}]
+			}`,
+			want: []string{
+				"/Statement/0/NotAction: SECURITY_WARNING ALLOW_WITH_NOT_ACTION: using NotAction with Allow grants every action except the ones listed; list the allowed actions with Action instead",
+				"/Statement/0/NotResource: SECURITY_WARNING ALLOW_WITH_NOT_RESOURCE: using NotResource with Allow grants access to every resource except the ones listed; list the allowed resources with Resource instead",
+			},
+		},
+		{
+			name: "PrincipalStar",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": [
+					{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::examplebucket/*"},
+					{"Effect": "Allow", "Principal": {"AWS": ["111122223333", "*"]}, "Action": "s3:GetObject", "Resource": "arn:aws:s3:::examplebucket/*"},
+					{"Effect": "Allow", "Principal": {"AWS": "*"}, "Action": "s3:GetObject", "Resource": "arn:aws:s3:::examplebucket/*", "Condition": {"StringEquals": {"aws:PrincipalOrgID": "o-1234567890"}}},
+					{"Effect": "Deny", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:s3:::examplebucket/*"}
+				]
+			}`,
+			want: []string{
+				`/Statement/0/Principal: SECURITY_WARNING PRINCIPAL_STAR_WITHOUT_CONDITION: the principal "*" grants access to anyone; add a condition to limit access, such as aws:PrincipalOrgID or aws:SourceAccount`,
+				`/Statement/1/Principal/AWS/1: SECURITY_WARNING PRINCIPAL_STAR_WITHOUT_CONDITION: the principal "*" grants access to anyone; add a condition to limit access, such as aws:PrincipalOrgID or aws:SourceAccount`,
+			},
+		},
+		{
+			name: "PassRole",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": [
+					{"Effect": "Allow", "Action": "iam:PassRole", "Resource": "*"},
+					{"Effect": "Allow", "Action": ["iam:Get*", "iam:Pass*"], "Resource": ["arn:aws:iam::111122223333:role/app", "*"]},
+					{"Effect": "Allow", "Action": "iam:GetRole", "Resource": "*"}
+				]
+			}`,
+			want: []string{
+				"/Statement/0/Resource: SECURITY_WARNING PASS_ROLE_WITH_STAR_IN_RESOURCE: iam:PassRole is allowed on all resources; limit the Resource to the ARNs of the roles that can be passed",
+				"/Statement/1/Resource/1: SECURITY_WARNING PASS_ROLE_WITH_STAR_IN_RESOURCE: iam:PassRole is allowed on all resources; limit the Resource to the ARNs of the roles that can be passed",
+			},
+		},
+		{
+			name: "RedundantActions",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": [
+					{"Effect": "Allow", "Action": ["s3:GetObject", "s3:Get*", "s3:get*", "s3:PutObject", "S3:PUTOBJECT"], "Resource": "*"},
+					{"Effect": "Deny", "NotAction": ["*", "iam:*"], "Resource": "*"}
+				]
+			}`,
+			want: []string{
+				`/Statement/0/Action/0: SUGGESTION REDUNDANT_ACTION: "s3:GetObject" is already matched by "s3:Get*"`,
+				`/Statement/0/Action/2: SUGGESTION REDUNDANT_ACTION: "s3:get*" is already matched by "s3:Get*"`,
+				`/Statement/0/Action/4: SUGGESTION REDUNDANT_ACTION: "S3:PUTOBJECT" is already matched by "s3:PutObject"`,
+				`/Statement/1/NotAction/1: SUGGESTION REDUNDANT_ACTION: "iam:*" is already matched by "*"`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p policy.Policy
+			if err := json.Unmarshal([]byte(tc.in), &p); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := Run(&p)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d findings '%v', want %d", len(got), got, len(tc.want))
+			}
+			for i, f := range got {
+				if f.String() != tc.want[i] {
+					t.Errorf("got '%s', want '%s'", f.String(), tc.want[i])
+				}
+			}
+		})
+	}
+}
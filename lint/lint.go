@@ -0,0 +1,171 @@
+/*
+Package lint checks policies for patterns that are valid but likely to be
+mistakes, similar to the policy checks of [IAM Access Analyzer].
+
+Each check has a stable ID and a severity, and reports findings with the JSON
+pointer of the offending element of the policy:
+
+	for _, f := range lint.Run(p) {
+		fmt.Println(f)
+	}
+	// /Statement/0/NotAction: SECURITY_WARNING ALLOW_WITH_NOT_ACTION: ...
+
+The checks in this package are registered by default, and additional checks
+can be added with [Register].
+
+[IAM Access Analyzer]: https://docs.aws.amazon.com/IAM/latest/UserGuide/access-analyzer-reference-policy-checks.html
+*/
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+// Finding severities, from most to least severe.
+const (
+	SeverityError           Severity = "ERROR"
+	SeveritySecurityWarning Severity = "SECURITY_WARNING"
+	SeverityWarning         Severity = "WARNING"
+	SeveritySuggestion      Severity = "SUGGESTION"
+)
+
+// rank orders severities from most to least severe.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 0
+	case SeveritySecurityWarning:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeveritySuggestion:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// AtLeast returns true if the severity is at least as severe as other.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() <= other.rank()
+}
+
+// Finding is a problem reported by a check.
+type Finding struct {
+	// CheckID is the ID of the check that reported the finding.
+	CheckID string
+	// Severity is the severity of the finding.
+	Severity Severity
+	// Message describes the problem.
+	Message string
+	// Path is the JSON pointer (RFC 6901) of the offending element, such as
+	// "/Statement/0/NotAction".
+	Path string
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", f.Path, f.Severity, f.CheckID, f.Message)
+}
+
+// Check is a policy check.
+type Check struct {
+	// ID is the stable identifier of the check, such as
+	// "ALLOW_WITH_NOT_ACTION".
+	ID string
+	// Severity is the severity of the findings reported by the check.
+	Severity Severity
+	// Description is a short description of what the check looks for.
+	Description string
+	// Run returns the problems found in a policy. The CheckID and Severity
+	// of each finding are set from the check when they are empty.
+	Run func(p *policy.Policy) []*Finding
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Check{}
+)
+
+// Register adds a check to the registry, replacing any existing check with
+// the same ID.
+func Register(c *Check) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.ID] = c
+}
+
+// Lookup returns the registered check with the given ID.
+func Lookup(id string) (*Check, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[id]
+	return c, ok
+}
+
+// Checks returns all registered checks, sorted by ID.
+func Checks() []*Check {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	resp := make([]*Check, 0, len(registry))
+	for _, c := range registry {
+		resp = append(resp, c)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].ID < resp[j].ID })
+	return resp
+}
+
+// Run runs all registered checks on a policy.
+func Run(p *policy.Policy) []*Finding {
+	return RunChecks(p, Checks()...)
+}
+
+// RunChecks runs the given checks on a policy. Findings are sorted by path,
+// with statements in document order, then by severity and check ID.
+func RunChecks(p *policy.Policy, checks ...*Check) []*Finding {
+	resp := []*Finding{}
+	if p == nil {
+		return resp
+	}
+	for _, c := range checks {
+		for _, f := range c.Run(p) {
+			if f.CheckID == "" {
+				f.CheckID = c.ID
+			}
+			if f.Severity == "" {
+				f.Severity = c.Severity
+			}
+			resp = append(resp, f)
+		}
+	}
+	sort.SliceStable(resp, func(i, j int) bool {
+		if resp[i].Path != resp[j].Path {
+			return jsonpointer.Less(resp[i].Path, resp[j].Path)
+		}
+		if resp[i].Severity != resp[j].Severity {
+			return resp[i].Severity.rank() < resp[j].Severity.rank()
+		}
+		return resp[i].CheckID < resp[j].CheckID
+	})
+	return resp
+}
+
+// ForEachStatement calls fn for each statement in the policy with the JSON
+// pointer of the statement, such as "/Statement/0". A singular statement is
+// not wrapped in an array, so its pointer is "/Statement".
+func ForEachStatement(p *policy.Policy, fn func(path string, s *policy.Statement)) {
+	if p == nil || p.Statements == nil {
+		return
+	}
+	statements := p.Statements.Values()
+	for i := range statements {
+		fn(p.Statements.Pointer(i), &statements[i])
+	}
+}
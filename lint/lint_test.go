@@ -0,0 +1,135 @@
+package lint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func TestRunChecks(t *testing.T) {
+	check := &Check{
+		ID:       "STATEMENT_WITHOUT_SID",
+		Severity: SeverityWarning,
+		Run: func(p *policy.Policy) []*Finding {
+			resp := []*Finding{}
+			ForEachStatement(p, func(path string, s *policy.Statement) {
+				if s.Sid == "" {
+					resp = append(resp, &Finding{Path: path, Message: "statement has no Sid"})
+				}
+			})
+			return resp
+		},
+	}
+	other := &Check{
+		ID: "ALWAYS",
+		Run: func(p *policy.Policy) []*Finding {
+			return []*Finding{{Path: "/Statement/1", Severity: SeverityError, Message: "always"}}
+		},
+	}
+
+	p := &policy.Policy{
+		Version: policy.VersionLatest,
+		Statements: policy.NewStatementOrSlice(
+			policy.Statement{Effect: policy.EffectAllow},
+			policy.Statement{Sid: "Named", Effect: policy.EffectAllow},
+			policy.Statement{Effect: policy.EffectDeny},
+		),
+	}
+	want := []string{
+		"/Statement/0: WARNING STATEMENT_WITHOUT_SID: statement has no Sid",
+		"/Statement/1: ERROR ALWAYS: always",
+		"/Statement/2: WARNING STATEMENT_WITHOUT_SID: statement has no Sid",
+	}
+	got := RunChecks(p, check, other)
+	if len(got) != len(want) {
+		t.Fatalf("got %d findings '%v', want %d", len(got), got, len(want))
+	}
+	for i, f := range got {
+		if f.String() != want[i] {
+			t.Errorf("got '%s', want '%s'", f.String(), want[i])
+		}
+	}
+}
+
+func TestRunChecksStatementOrder(t *testing.T) {
+	check := &Check{
+		ID:       "ALWAYS",
+		Severity: SeverityWarning,
+		Run: func(p *policy.Policy) []*Finding {
+			resp := []*Finding{}
+			ForEachStatement(p, func(path string, s *policy.Statement) {
+				resp = append(resp, &Finding{Path: path, Message: "always"})
+			})
+			return resp
+		},
+	}
+	statements := make([]policy.Statement, 12)
+	for i := range statements {
+		statements[i] = policy.Statement{Effect: policy.EffectAllow}
+	}
+	p := &policy.Policy{Version: policy.VersionLatest, Statements: policy.NewStatementOrSlice(statements...)}
+	got := RunChecks(p, check)
+	if len(got) != len(statements) {
+		t.Fatalf("got %d findings '%v', want %d", len(got), got, len(statements))
+	}
+	for i, f := range got {
+		if want := p.Statements.Pointer(i); f.Path != want {
+			t.Errorf("got '%s', want '%s'", f.Path, want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	c := &Check{ID: "CUSTOM_CHECK", Severity: SeveritySuggestion, Run: func(*policy.Policy) []*Finding { return nil }}
+	Register(c)
+	defer func() {
+		registryMu.Lock()
+		delete(registry, c.ID)
+		registryMu.Unlock()
+	}()
+	if got, ok := Lookup(c.ID); !ok || got != c {
+		t.Errorf("got '%v', want '%v'", got, c)
+	}
+	found := false
+	for _, check := range Checks() {
+		if check.ID == c.ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("registered check %s not returned by Checks", c.ID)
+	}
+}
+
+func TestSingularStatementPath(t *testing.T) {
+	var p policy.Policy
+	in := `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "NotAction": "s3:*", "Resource": "*"}}`
+	if err := json.Unmarshal([]byte(in), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := Run(&p)
+	want := "/Statement/NotAction: SECURITY_WARNING ALLOW_WITH_NOT_ACTION: using NotAction with Allow grants every action except the ones listed; list the allowed actions with Action instead"
+	if len(got) != 1 || got[0].String() != want {
+		t.Errorf("got '%v', want '%s'", got, want)
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	cases := []struct {
+		s     Severity
+		other Severity
+		want  bool
+	}{
+		{s: SeverityError, other: SeverityWarning, want: true},
+		{s: SeveritySecurityWarning, other: SeveritySecurityWarning, want: true},
+		{s: SeveritySuggestion, other: SeverityWarning, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.s)+"/"+string(tc.other), func(t *testing.T) {
+			if got := tc.s.AtLeast(tc.other); got != tc.want {
+				t.Errorf("got '%t', want '%t'", got, tc.want)
+			}
+		})
+	}
+}
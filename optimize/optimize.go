@@ -0,0 +1,379 @@
+/*
+Package optimize rewrites a policy into fewer, simpler statements without
+changing the requests it allows or denies:
+
+	optimized, changes := optimize.Optimize(p)
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	// /Statement/1: MergeStatements: merged Action into /Statement/0
+
+The rewrites are conservative. Values are compared as wildcard patterns, and
+values that hold policy variables such as ${aws:username} or CloudFormation
+intrinsic functions only match themselves. Principals and conditions must be
+equal for statements to be merged, and a statement is only removed in favor of
+another whose condition is equal or absent.
+
+The paths of the reported changes point into the policy passed to Optimize.
+*/
+package optimize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
+	"github.com/micahhausler/aws-iam-policy/internal/wildcard"
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+// Kinds of changes made by Optimize.
+const (
+	// KindRedundantValue is a value of an element that is matched by another
+	// value of the same element, such as "s3:GetObject" next to "s3:Get*".
+	KindRedundantValue = "RemoveRedundantValue"
+	// KindDuplicateStatement is a statement that only differs from another
+	// statement in its Sid, value order or encoding.
+	KindDuplicateStatement = "RemoveDuplicateStatement"
+	// KindShadowedStatement is a statement that matches no request that is
+	// not matched by another statement with the same Effect, or that only
+	// allows requests denied by a Deny statement.
+	KindShadowedStatement = "RemoveShadowedStatement"
+	// KindMergeStatements is a statement that was merged into another
+	// statement that differs only in its Action or Resource.
+	KindMergeStatements = "MergeStatements"
+)
+
+// Change is a transformation made by Optimize.
+type Change struct {
+	// Kind is one of the Kind* constants.
+	Kind string
+	// Path is the JSON pointer (RFC 6901) of the changed element in the
+	// original policy, such as "/Statement/1".
+	Path string
+	// Message describes the change.
+	Message string
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Path, c.Kind, c.Message)
+}
+
+// statement is a statement being optimized along with the JSON pointer of
+// the statement it came from in the original policy.
+type statement struct {
+	path string
+	policy.Statement
+}
+
+// Optimize returns an optimized copy of the policy, along with the changes
+// made, in the order they were made. The policy itself is not modified. The
+// key order recorded by PreserveKeyOrder is only kept when no statement was
+// removed.
+func Optimize(p *policy.Policy) (*policy.Policy, []*Change) {
+	changes := []*Change{}
+	if p == nil {
+		return nil, changes
+	}
+	resp := p.Clone()
+	if p.Statements == nil {
+		return resp, changes
+	}
+	o := &optimizer{changes: changes}
+	values := resp.Statements.Values()
+	statements := make([]*statement, len(values))
+	for i := range values {
+		statements[i] = &statement{path: p.Statements.Pointer(i), Statement: values[i]}
+	}
+
+	for _, s := range statements {
+		o.removeRedundantValues(s)
+	}
+	statements = o.removeDuplicates(statements)
+	statements = o.removeShadowed(statements)
+	statements = o.merge(statements)
+
+	if len(statements) == len(values) {
+		// Nothing was removed, so keep the layout recorded by
+		// PreserveKeyOrder.
+		for i, s := range statements {
+			values[i] = s.Statement
+		}
+		return resp, o.changes
+	}
+	optimized := make([]policy.Statement, len(statements))
+	for i, s := range statements {
+		optimized[i] = s.Statement
+	}
+	if p.Statements.Singular() && len(optimized) == 1 {
+		resp.Statements = policy.NewSingularStatementOrSlice(optimized[0])
+	} else {
+		resp.Statements = policy.NewStatementOrSlice(optimized...)
+	}
+	return resp, o.changes
+}
+
+type optimizer struct {
+	changes []*Change
+}
+
+func (o *optimizer) add(kind, path, format string, args ...interface{}) {
+	o.changes = append(o.changes, &Change{Kind: kind, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// removeRedundantValues removes the values of the Action, NotAction, Resource
+// and NotResource elements that are matched by another value of the same
+// element. Each element matches the union of its values, so this does not
+// change the requests it matches.
+func (o *optimizer) removeRedundantValues(s *statement) {
+	s.Action = o.compact(s.path, "Action", s.Action, true)
+	s.NotAction = o.compact(s.path, "NotAction", s.NotAction, true)
+	s.Resource = o.compact(s.path, "Resource", s.Resource, false)
+	s.NotResource = o.compact(s.path, "NotResource", s.NotResource, false)
+}
+
+func (o *optimizer) compact(path, element string, values *policy.StringOrSlice, fold bool) *policy.StringOrSlice {
+	if values == nil || values.HasIntrinsics() {
+		return values
+	}
+	vs := values.Values()
+	keep := []string{}
+	for i, v := range vs {
+		redundant := false
+		for j, other := range vs {
+			if i == j || !subsumes(other, v, fold) {
+				continue
+			}
+			// Of two values matching each other, the first is kept.
+			if !subsumes(v, other, fold) || j < i {
+				redundant = true
+				break
+			}
+		}
+		if redundant {
+			o.add(KindRedundantValue, jsonpointer.Append(path, element), "removed %q, which is matched by another value", v)
+			continue
+		}
+		keep = append(keep, v)
+	}
+	if len(keep) == len(vs) {
+		return values
+	}
+	return policy.NewStringOrSlice(values.IsSingular(), keep...)
+}
+
+// subsumes reports whether pattern matches every value matched by other.
+// Values with policy variables only match themselves.
+func subsumes(pattern, other string, fold bool) bool {
+	if strings.Contains(pattern, "${") || strings.Contains(other, "${") {
+		if fold {
+			return strings.EqualFold(pattern, other)
+		}
+		return pattern == other
+	}
+	if fold {
+		return wildcard.SubsumesFold(pattern, other)
+	}
+	return wildcard.Subsumes(pattern, other)
+}
+
+// covers reports whether every value matched by b is matched by a value of a.
+func covers(a, b *policy.StringOrSlice, fold bool) bool {
+	if a.HasIntrinsics() || b.HasIntrinsics() {
+		return a.Equal(b, policy.IgnoreSingular(), policy.IgnoreOrder())
+	}
+	for _, v := range b.Values() {
+		covered := false
+		for _, pattern := range a.Values() {
+			if subsumes(pattern, v, fold) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// coversElement reports whether the element pair of a, such as Action and
+// NotAction, matches every value matched by that of b.
+func coversElement(a, notA, b, notB *policy.StringOrSlice, fold bool) bool {
+	switch {
+	case a == nil && notA == nil:
+		return b == nil && notB == nil
+	case a != nil && b != nil:
+		return covers(a, b, fold)
+	case notA != nil && notB != nil:
+		// a excludes fewer values than b.
+		return covers(notB, notA, fold)
+	case a != nil && notB != nil:
+		return covers(a, policy.NewStringOrSlice(true, "*"), fold)
+	default:
+		return false
+	}
+}
+
+var equalOpts = []policy.EqualOption{policy.IgnoreSingular(), policy.IgnoreOrder()}
+
+// sameCondition reports whether two statements have equal conditions.
+func sameCondition(a, b *statement) bool {
+	ca := policy.Statement{Condition: a.Condition}
+	cb := policy.Statement{Condition: b.Condition}
+	return ca.Equal(&cb, equalOpts...)
+}
+
+// samePrincipals reports whether two statements have equal Principal and
+// NotPrincipal elements.
+func samePrincipals(a, b *statement) bool {
+	return a.Principal.Equal(b.Principal, equalOpts...) && a.NotPrincipal.Equal(b.NotPrincipal, equalOpts...)
+}
+
+// removeDuplicates removes statements equal to an earlier statement, ignoring
+// their Sid.
+func (o *optimizer) removeDuplicates(statements []*statement) []*statement {
+	resp := []*statement{}
+	for _, s := range statements {
+		duplicate := false
+		for _, kept := range resp {
+			a, b := kept.Statement, s.Statement
+			a.Sid, b.Sid = "", ""
+			if a.Equal(&b, equalOpts...) {
+				o.add(KindDuplicateStatement, s.path, "removed duplicate of %s", kept.path)
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			resp = append(resp, s)
+		}
+	}
+	return resp
+}
+
+// shadows reports whether every request matched by b is matched by a. The
+// condition of a must be absent or equal to that of b.
+func shadows(a, b *statement) bool {
+	if len(a.Condition) > 0 && !sameCondition(a, b) {
+		return false
+	}
+	if len(a.Extra) > 0 || len(b.Extra) > 0 {
+		return false
+	}
+	// The "*" principal matches every principal named by b.
+	if !(isAllPrincipals(a.Principal) && b.Principal != nil) && !a.Principal.Equal(b.Principal, equalOpts...) {
+		return false
+	}
+	return a.NotPrincipal.Equal(b.NotPrincipal, equalOpts...) &&
+		coversElement(a.Action, a.NotAction, b.Action, b.NotAction, true) &&
+		coversElement(a.Resource, a.NotResource, b.Resource, b.NotResource, false)
+}
+
+func isAllPrincipals(p *policy.Principal) bool {
+	if p == nil {
+		return false
+	}
+	kinds := p.Kinds()
+	return len(kinds) == 1 && kinds[0] == policy.PrincipalKindAll
+}
+
+// removeShadowed removes statements whose requests are all matched by another
+// statement with the same Effect, and Allow statements whose requests are all
+// denied. Of two statements that match the same requests, the first is kept.
+func (o *optimizer) removeShadowed(statements []*statement) []*statement {
+	removed := make([]bool, len(statements))
+	for i, s := range statements {
+		for j, other := range statements {
+			if i == j || removed[j] || !shadows(other, s) {
+				continue
+			}
+			if other.Effect == s.Effect {
+				if shadows(s, other) && i < j {
+					continue
+				}
+				o.add(KindShadowedStatement, s.path, "removed statement matched by %s", other.path)
+				removed[i] = true
+				break
+			}
+			if s.Effect == policy.EffectAllow && other.Effect == policy.EffectDeny {
+				o.add(KindShadowedStatement, s.path, "removed statement denied by %s", other.path)
+				removed[i] = true
+				break
+			}
+		}
+	}
+	resp := []*statement{}
+	for i, s := range statements {
+		if !removed[i] {
+			resp = append(resp, s)
+		}
+	}
+	return resp
+}
+
+// merge combines statements that differ only in their Action, or only in
+// their Resource, into the first of them, until no more can be merged.
+func (o *optimizer) merge(statements []*statement) []*statement {
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(statements) && !merged; i++ {
+			for j := i + 1; j < len(statements) && !merged; j++ {
+				if o.mergeInto(statements[i], statements[j]) {
+					statements = append(statements[:j], statements[j+1:]...)
+					merged = true
+				}
+			}
+		}
+	}
+	return statements
+}
+
+// mergeInto merges b into a if they differ only in their Action or only in
+// their Resource, returning true if they were merged.
+func (o *optimizer) mergeInto(a, b *statement) bool {
+	if a.Effect != b.Effect || !samePrincipals(a, b) || !sameCondition(a, b) {
+		return false
+	}
+	if len(a.Extra) > 0 || len(b.Extra) > 0 {
+		return false
+	}
+	sameActions := a.Action.Equal(b.Action, equalOpts...) && a.NotAction.Equal(b.NotAction, equalOpts...)
+	sameResources := a.Resource.Equal(b.Resource, equalOpts...) && a.NotResource.Equal(b.NotResource, equalOpts...)
+	var element string
+	switch {
+	case sameResources && a.Action != nil && b.Action != nil && !a.Action.HasIntrinsics() && !b.Action.HasIntrinsics():
+		element = "Action"
+		a.Action = union(a.Action, b.Action, true)
+	case sameActions && a.Resource != nil && b.Resource != nil && !a.Resource.HasIntrinsics() && !b.Resource.HasIntrinsics():
+		element = "Resource"
+		a.Resource = union(a.Resource, b.Resource, false)
+	default:
+		return false
+	}
+	if b.Sid != "" {
+		o.add(KindMergeStatements, b.path, "merged %s into %s, dropping Sid %q", element, a.path, b.Sid)
+	} else {
+		o.add(KindMergeStatements, b.path, "merged %s into %s", element, a.path)
+	}
+	o.removeRedundantValues(a)
+	return true
+}
+
+// union returns the values of a followed by the values of b not in a.
+func union(a, b *policy.StringOrSlice, fold bool) *policy.StringOrSlice {
+	values := append([]string{}, a.Values()...)
+	for _, v := range b.Values() {
+		found := false
+		for _, existing := range values {
+			if existing == v || (fold && strings.EqualFold(existing, v)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, v)
+		}
+	}
+	return policy.NewStringOrSlice(false, values...)
+}
@@ -0,0 +1,142 @@
+package optimize
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func TestOptimize(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    string
+		changes []string
+	}{
+		{
+			name: "Unchanged",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/*"},
+				{"Effect": "Allow", "Action": "s3:PutObject", "Resource": "arn:aws:s3:::b/*"}]}`,
+			want: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::a/*"},{"Effect":"Allow","Action":"s3:PutObject","Resource":"arn:aws:s3:::b/*"}]}`,
+		},
+		{
+			name: "MergeActions",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Sid": "Get", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/*"},
+				{"Sid": "Put", "Effect": "Allow", "Action": "s3:PutObject", "Resource": ["arn:aws:s3:::a/*"]},
+				{"Effect": "Allow", "Action": "s3:DeleteObject", "Resource": "arn:aws:s3:::a/*"}]}`,
+			want: `{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject","s3:PutObject","s3:DeleteObject"],"Effect":"Allow","Resource":"arn:aws:s3:::a/*","Sid":"Get"}]}`,
+			changes: []string{
+				`/Statement/1: MergeStatements: merged Action into /Statement/0, dropping Sid "Put"`,
+				`/Statement/2: MergeStatements: merged Action into /Statement/0`,
+			},
+		},
+		{
+			name: "MergeResources",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/*", "Condition": {"Bool": {"aws:SecureTransport": "true"}}},
+				{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::b/*", "Condition": {"Bool": {"aws:SecureTransport": "true"}}},
+				{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::c/*"}]}`,
+			want: `{"Version":"2012-10-17","Statement":[{"Action":"s3:GetObject","Condition":{"Bool":{"aws:SecureTransport":"true"}},"Effect":"Allow","Resource":["arn:aws:s3:::a/*","arn:aws:s3:::b/*"]},{"Action":"s3:GetObject","Effect":"Allow","Resource":"arn:aws:s3:::c/*"}]}`,
+			changes: []string{
+				`/Statement/1: MergeStatements: merged Resource into /Statement/0`,
+			},
+		},
+		{
+			name: "RedundantValues",
+			in: `{"Version": "2012-10-17", "Statement": {
+				"Effect": "Allow",
+				"Action": ["s3:GetObject", "s3:Get*", "S3:get*"],
+				"Resource": ["arn:aws:s3:::a/${aws:username}", "arn:aws:s3:::a/*", "arn:aws:s3:::a/logs/*"]}}`,
+			want: `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":["s3:Get*"],"Resource":["arn:aws:s3:::a/${aws:username}","arn:aws:s3:::a/*"]}}`,
+			changes: []string{
+				`/Statement/Action: RemoveRedundantValue: removed "s3:GetObject", which is matched by another value`,
+				`/Statement/Action: RemoveRedundantValue: removed "S3:get*", which is matched by another value`,
+				`/Statement/Resource: RemoveRedundantValue: removed "arn:aws:s3:::a/logs/*", which is matched by another value`,
+			},
+		},
+		{
+			name: "Duplicates",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Sid": "A", "Effect": "Allow", "Action": ["s3:GetObject", "s3:PutObject"], "Resource": "*"},
+				{"Sid": "B", "Effect": "Allow", "Action": ["s3:PutObject", "s3:GetObject"], "Resource": ["*"]}]}`,
+			want: `{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject","s3:PutObject"],"Effect":"Allow","Resource":"*","Sid":"A"}]}`,
+			changes: []string{
+				`/Statement/1: RemoveDuplicateStatement: removed duplicate of /Statement/0`,
+			},
+		},
+		{
+			name: "Shadowed",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/logs/*", "Condition": {"Bool": {"aws:SecureTransport": "true"}}},
+				{"Effect": "Allow", "Action": "s3:Get*", "Resource": "arn:aws:s3:::a/*"},
+				{"Effect": "Allow", "Action": "iam:PassRole", "Resource": "*"},
+				{"Effect": "Deny", "Action": "iam:Get*", "Resource": "arn:aws:iam::*"},
+				{"Effect": "Deny", "Action": "iam:*", "Resource": "*"}]}`,
+			want: `{"Version":"2012-10-17","Statement":[{"Action":"s3:Get*","Effect":"Allow","Resource":"arn:aws:s3:::a/*"},{"Action":"iam:*","Effect":"Deny","Resource":"*"}]}`,
+			changes: []string{
+				`/Statement/0: RemoveShadowedStatement: removed statement matched by /Statement/1`,
+				`/Statement/2: RemoveShadowedStatement: removed statement denied by /Statement/4`,
+				`/Statement/3: RemoveShadowedStatement: removed statement matched by /Statement/4`,
+			},
+		},
+		{
+			name: "NotShadowed",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/*"},
+				{"Effect": "Allow", "Action": "s3:Get*", "Resource": "arn:aws:s3:::a/*", "Condition": {"Bool": {"aws:SecureTransport": "true"}}},
+				{"Effect": "Allow", "Action": "s3:Get*", "Resource": "arn:aws:s3:::a/${aws:username}"},
+				{"Effect": "Deny", "Action": "s3:*", "Resource": "arn:aws:s3:::a/*", "Condition": {"Bool": {"aws:SecureTransport": "false"}}}]}`,
+			want: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::a/*"},{"Effect":"Allow","Action":"s3:Get*","Resource":"arn:aws:s3:::a/*","Condition":{"Bool":{"aws:SecureTransport":"true"}}},{"Effect":"Allow","Action":"s3:Get*","Resource":"arn:aws:s3:::a/${aws:username}"},{"Effect":"Deny","Action":"s3:*","Resource":"arn:aws:s3:::a/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`,
+		},
+		{
+			name: "Principals",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/*"},
+				{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/*"},
+				{"Effect": "Allow", "Principal": {"AWS": "444455556666"}, "Action": "s3:PutObject", "Resource": "arn:aws:s3:::a/*"}]}`,
+			want: `{"Version":"2012-10-17","Statement":[{"Action":"s3:GetObject","Effect":"Allow","Principal":"*","Resource":"arn:aws:s3:::a/*"},{"Action":"s3:PutObject","Effect":"Allow","Principal":{"AWS":"444455556666"},"Resource":"arn:aws:s3:::a/*"}]}`,
+			changes: []string{
+				`/Statement/0: RemoveShadowedStatement: removed statement matched by /Statement/1`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := policy.Unmarshal([]byte(tc.in), policy.PreserveKeyOrder())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			before, _ := json.Marshal(p)
+			got, changes := Optimize(p)
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(b) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(b), tc.want)
+			}
+			if len(changes) != len(tc.changes) {
+				t.Fatalf("got %d changes '%v', want %d", len(changes), changes, len(tc.changes))
+			}
+			for i, c := range changes {
+				if c.String() != tc.changes[i] {
+					t.Errorf("got '%s', want '%s'", c.String(), tc.changes[i])
+				}
+			}
+			after, _ := json.Marshal(p)
+			if string(after) != string(before) {
+				t.Errorf("got '%s', want '%s'", string(after), string(before))
+			}
+		})
+	}
+}
+
+func TestOptimizeNil(t *testing.T) {
+	got, changes := Optimize(nil)
+	if got != nil || len(changes) != 0 {
+		t.Errorf("got '%v' and %d changes, want nil and none", got, len(changes))
+	}
+}
@@ -0,0 +1,175 @@
+package policy
+
+import "encoding/json"
+
+// Clone returns a deep copy of the policy, including the key order recorded
+// by PreserveKeyOrder and the duplicate keys reported by Validate.
+func (p *Policy) Clone() *Policy {
+	if p == nil {
+		return nil
+	}
+	resp := &Policy{
+		Id:         p.Id,
+		Statements: p.Statements.Clone(),
+		Version:    p.Version,
+		Extra:      copyExtra(p.Extra),
+		keys:       copyStrings(p.keys),
+	}
+	for _, err := range p.duplicates {
+		dup := *err
+		resp.duplicates = append(resp.duplicates, &dup)
+	}
+	return resp
+}
+
+// Clone returns a deep copy of the statement.
+func (s *Statement) Clone() *Statement {
+	if s == nil {
+		return nil
+	}
+	return &Statement{
+		Action:       s.Action.Clone(),
+		Condition:    cloneCondition(s.Condition),
+		Effect:       s.Effect,
+		NotAction:    s.NotAction.Clone(),
+		NotResource:  s.NotResource.Clone(),
+		Principal:    s.Principal.Clone(),
+		NotPrincipal: s.NotPrincipal.Clone(),
+		Resource:     s.Resource.Clone(),
+		Sid:          s.Sid,
+		Extra:        copyExtra(s.Extra),
+	}
+}
+
+// Clone returns a deep copy of the StatementOrSlice.
+func (s *StatementOrSlice) Clone() *StatementOrSlice {
+	if s == nil {
+		return nil
+	}
+	resp := &StatementOrSlice{singular: s.singular}
+	if s.values != nil {
+		resp.values = make([]Statement, len(s.values))
+		for i := range s.values {
+			resp.values[i] = *s.values[i].Clone()
+		}
+	}
+	for _, layout := range s.layouts {
+		resp.layouts = append(resp.layouts, layout.clone())
+	}
+	return resp
+}
+
+func (l *statementLayout) clone() *statementLayout {
+	if l == nil {
+		return nil
+	}
+	resp := &statementLayout{keys: copyStrings(l.keys)}
+	if l.condition != nil {
+		resp.condition = &conditionLayout{operators: copyStrings(l.condition.operators)}
+		if l.condition.keys != nil {
+			resp.condition.keys = make(map[string][]string, len(l.condition.keys))
+			for operator, keys := range l.condition.keys {
+				resp.condition.keys[operator] = copyStrings(keys)
+			}
+		}
+	}
+	return resp
+}
+
+// Clone returns a deep copy of the StringOrSlice.
+func (s *StringOrSlice) Clone() *StringOrSlice {
+	if s == nil {
+		return nil
+	}
+	resp := &StringOrSlice{values: copyStrings(s.values), singular: s.singular}
+	for _, intrinsic := range s.intrinsics {
+		resp.intrinsics = append(resp.intrinsics, intrinsic.clone())
+	}
+	return resp
+}
+
+// Clone returns a deep copy of the Principal.
+func (p *Principal) Clone() *Principal {
+	if p == nil {
+		return nil
+	}
+	resp := &Principal{str: p.str, Extra: copyExtra(p.Extra)}
+	if p.principal != nil {
+		resp.principal = &principal{
+			AWS:           p.principal.AWS.Clone(),
+			CanonicalUser: p.principal.CanonicalUser.Clone(),
+			Federated:     p.principal.Federated.Clone(),
+			Service:       p.principal.Service.Clone(),
+			keys:          copyStrings(p.principal.keys),
+		}
+	}
+	return resp
+}
+
+// Clone returns a deep copy of the ConditionValue.
+func (c *ConditionValue) Clone() *ConditionValue {
+	if c == nil {
+		return nil
+	}
+	resp := &ConditionValue{
+		strValues: copyStrings(c.strValues),
+		numText:   copyStrings(c.numText),
+		singular:  c.singular,
+	}
+	if c.boolValues != nil {
+		resp.boolValues = append([]bool{}, c.boolValues...)
+	}
+	if c.numValues != nil {
+		resp.numValues = append([]float64{}, c.numValues...)
+	}
+	if c.kinds != nil {
+		resp.kinds = append([]ConditionElementKind{}, c.kinds...)
+	}
+	for _, intrinsic := range c.intrinsics {
+		resp.intrinsics = append(resp.intrinsics, intrinsic.clone())
+	}
+	return resp
+}
+
+func (i *Intrinsic) clone() *Intrinsic {
+	if i == nil {
+		return nil
+	}
+	return &Intrinsic{Function: i.Function, Args: append(json.RawMessage(nil), i.Args...)}
+}
+
+func cloneCondition(c Condition) Condition {
+	if c == nil {
+		return nil
+	}
+	resp := make(Condition, len(c))
+	for operator, block := range c {
+		if block == nil {
+			resp[operator] = nil
+			continue
+		}
+		resp[operator] = make(map[string]*ConditionValue, len(block))
+		for key, value := range block {
+			resp[operator][key] = value.Clone()
+		}
+	}
+	return resp
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func copyExtra(extra map[string]json.RawMessage) map[string]json.RawMessage {
+	if extra == nil {
+		return nil
+	}
+	resp := make(map[string]json.RawMessage, len(extra))
+	for k, v := range extra {
+		resp[k] = append(json.RawMessage(nil), v...)
+	}
+	return resp
+}
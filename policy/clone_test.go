@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPolicyClone(t *testing.T) {
+	in := `{
+  "Version": "2012-10-17",
+  "Id": "Clone",
+  "Future": [1, 2],
+  "Statement": [
+    {
+      "Sid": "One",
+      "Effect": "Allow",
+      "Principal": {"Service": "ec2.amazonaws.com", "AWS": ["111122223333"]},
+      "Action": "s3:GetObject",
+      "Resource": [{"Fn::GetAtt": ["Bucket", "Arn"]}, "arn:aws:s3:::other"],
+      "Condition": {"NumericLessThan": {"s3:max-keys": [1.0, "2", true]}, "Bool": {"aws:SecureTransport": "true"}}
+    }
+  ]
+}`
+	p, err := Unmarshal([]byte(in), PreserveKeyOrder(), AllowIntrinsics(), UnknownFields(UnknownFieldsCapture))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := json.Marshal(p)
+	clone := p.Clone()
+	got, _ := json.Marshal(clone)
+	if string(got) != string(want) {
+		t.Errorf("got '%s', want '%s'", string(got), string(want))
+	}
+	if !clone.Equal(p) {
+		t.Errorf("got clone not equal to the policy")
+	}
+
+	// Changing the clone does not change the policy.
+	s := &clone.Statements.Values()[0]
+	s.Action.Add("s3:PutObject")
+	s.Principal.AddAWS("444455556666")
+	s.Resource.Values()[1] = "arn:aws:s3:::changed"
+	s.Condition["Bool"]["aws:SecureTransport"].AddString("false")
+	clone.Extra["Future"][1] = '9'
+	after, _ := json.Marshal(p)
+	if string(after) != string(want) {
+		t.Errorf("got '%s', want '%s'", string(after), string(want))
+	}
+	if clone.Equal(p) {
+		t.Errorf("got changed clone equal to the policy")
+	}
+}
+
+func TestCloneNil(t *testing.T) {
+	if (*Policy)(nil).Clone() != nil ||
+		(*Statement)(nil).Clone() != nil ||
+		(*StatementOrSlice)(nil).Clone() != nil ||
+		(*StringOrSlice)(nil).Clone() != nil ||
+		(*Principal)(nil).Clone() != nil ||
+		(*ConditionValue)(nil).Clone() != nil {
+		t.Errorf("got non-nil clone of nil value")
+	}
+}
@@ -0,0 +1,167 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ErrorUnknownConditionOperator = "unknown condition operator"
+	ErrorEmptyConditionKey        = "condition key is empty"
+)
+
+// Condition is the Condition element of a statement. It maps condition
+// operator keys, such as "StringEquals", to a block of condition keys and
+// their values. It has the same JSON encoding as a plain nested map.
+type Condition map[string]map[string]*ConditionValue
+
+// ConditionBlock is the set of condition keys and values for a single
+// condition operator.
+type ConditionBlock map[string]*ConditionValue
+
+// ConditionEntry is a single condition key and value along with the operator
+// it is compared with.
+type ConditionEntry struct {
+	Operator ConditionOperator
+	Key      string
+	Value    *ConditionValue
+}
+
+// Operators returns the operator keys of the Condition in sorted order.
+func (c Condition) Operators() []string {
+	resp := make([]string, 0, len(c))
+	for operator := range c {
+		resp = append(resp, operator)
+	}
+	sort.Strings(resp)
+	return resp
+}
+
+// Block returns the block for an operator key, or nil if there is none.
+func (c Condition) Block(operator string) ConditionBlock {
+	return ConditionBlock(c[operator])
+}
+
+// Entries returns every condition in the Condition, sorted by operator key
+// and then by condition key. It returns an error if an operator key is
+// malformed.
+func (c Condition) Entries() ([]ConditionEntry, error) {
+	resp := []ConditionEntry{}
+	for _, operator := range c.Operators() {
+		op, err := ParseConditionOperator(operator)
+		if err != nil {
+			return nil, err
+		}
+		block := c.Block(operator)
+		for _, key := range block.Keys() {
+			resp = append(resp, ConditionEntry{Operator: op, Key: key, Value: block[key]})
+		}
+	}
+	return resp, nil
+}
+
+// Get returns the value for an operator key and condition key. Condition keys
+// are matched case-insensitively, as IAM does.
+func (c Condition) Get(operator, key string) (*ConditionValue, bool) {
+	return c.Block(operator).Get(key)
+}
+
+// Lookup returns every condition on a condition key, across all operators,
+// sorted by operator key. Condition keys are matched case-insensitively.
+// Operator keys that are malformed are skipped.
+func (c Condition) Lookup(key string) []ConditionEntry {
+	resp := []ConditionEntry{}
+	for _, operator := range c.Operators() {
+		op, err := ParseConditionOperator(operator)
+		if err != nil {
+			continue
+		}
+		block := c.Block(operator)
+		for _, k := range block.Keys() {
+			if strings.EqualFold(k, key) {
+				resp = append(resp, ConditionEntry{Operator: op, Key: k, Value: block[k]})
+			}
+		}
+	}
+	return resp
+}
+
+// Add sets the value for an operator key and condition key, replacing any
+// existing value. The Condition is allocated if it is nil, so conditions can
+// be added to a statement that has none. It returns an error if the operator
+// key is malformed or the operator is not one IAM defines.
+func (c *Condition) Add(operator, key string, value *ConditionValue) error {
+	op, err := ParseConditionOperator(operator)
+	if err != nil {
+		return err
+	}
+	if !op.IsKnown() {
+		return fmt.Errorf("%s %q", ErrorUnknownConditionOperator, operator)
+	}
+	if key == "" {
+		return fmt.Errorf("%s %s", operator, ErrorEmptyConditionKey)
+	}
+	if *c == nil {
+		*c = Condition{}
+	}
+	block, ok := (*c)[operator]
+	if !ok {
+		block = map[string]*ConditionValue{}
+		(*c)[operator] = block
+	}
+	if existing, ok := ConditionBlock(block).key(key); ok {
+		key = existing
+	}
+	block[key] = value
+	return nil
+}
+
+// Remove deletes the value for an operator key and condition key. The
+// operator is removed as well if it has no keys left. It returns true if a
+// value was removed.
+func (c Condition) Remove(operator, key string) bool {
+	block := c.Block(operator)
+	existing, ok := block.key(key)
+	if !ok {
+		return false
+	}
+	delete(block, existing)
+	if len(block) == 0 {
+		delete(c, operator)
+	}
+	return true
+}
+
+// Keys returns the condition keys of the block in sorted order.
+func (b ConditionBlock) Keys() []string {
+	resp := make([]string, 0, len(b))
+	for key := range b {
+		resp = append(resp, key)
+	}
+	sort.Strings(resp)
+	return resp
+}
+
+// Get returns the value of a condition key. Condition keys are matched
+// case-insensitively.
+func (b ConditionBlock) Get(key string) (*ConditionValue, bool) {
+	existing, ok := b.key(key)
+	if !ok {
+		return nil, false
+	}
+	return b[existing], true
+}
+
+// key returns the key in the block that matches the given condition key.
+func (b ConditionBlock) key(key string) (string, bool) {
+	if _, ok := b[key]; ok {
+		return key, true
+	}
+	for _, k := range b.Keys() {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
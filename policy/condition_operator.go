@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition operators. See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_elements_condition_operators.html
+const (
+	OperatorStringEquals              = "StringEquals"
+	OperatorStringNotEquals           = "StringNotEquals"
+	OperatorStringEqualsIgnoreCase    = "StringEqualsIgnoreCase"
+	OperatorStringNotEqualsIgnoreCase = "StringNotEqualsIgnoreCase"
+	OperatorStringLike                = "StringLike"
+	OperatorStringNotLike             = "StringNotLike"
+
+	OperatorNumericEquals            = "NumericEquals"
+	OperatorNumericNotEquals         = "NumericNotEquals"
+	OperatorNumericLessThan          = "NumericLessThan"
+	OperatorNumericLessThanEquals    = "NumericLessThanEquals"
+	OperatorNumericGreaterThan       = "NumericGreaterThan"
+	OperatorNumericGreaterThanEquals = "NumericGreaterThanEquals"
+
+	OperatorDateEquals            = "DateEquals"
+	OperatorDateNotEquals         = "DateNotEquals"
+	OperatorDateLessThan          = "DateLessThan"
+	OperatorDateLessThanEquals    = "DateLessThanEquals"
+	OperatorDateGreaterThan       = "DateGreaterThan"
+	OperatorDateGreaterThanEquals = "DateGreaterThanEquals"
+
+	OperatorBool         = "Bool"
+	OperatorBinaryEquals = "BinaryEquals"
+
+	OperatorIpAddress    = "IpAddress"
+	OperatorNotIpAddress = "NotIpAddress"
+
+	OperatorArnEquals    = "ArnEquals"
+	OperatorArnLike      = "ArnLike"
+	OperatorArnNotEquals = "ArnNotEquals"
+	OperatorArnNotLike   = "ArnNotLike"
+
+	OperatorNull = "Null"
+
+	// OperatorSuffixIfExists can be appended to any operator other than Null
+	// to make the condition evaluate to true when the key is not present in
+	// the request.
+	OperatorSuffixIfExists = "IfExists"
+)
+
+// Set operators qualify a condition operator to compare multivalued request
+// keys. See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_condition-single-vs-multi-valued-context-keys.html
+const (
+	QualifierForAllValues = "ForAllValues"
+	QualifierForAnyValue  = "ForAnyValue"
+
+	ErrorInvalidConditionOperator = "invalid condition operator"
+)
+
+// ConditionOperator is a condition operator key from a Condition element,
+// such as "ForAnyValue:StringLikeIfExists", split into its parts.
+type ConditionOperator struct {
+	// Qualifier is the set operator, QualifierForAllValues or
+	// QualifierForAnyValue, or empty for single-valued comparisons.
+	Qualifier string
+	// Name is the base operator name, such as "StringLike".
+	Name string
+	// IfExists is true if the operator has the IfExists suffix.
+	IfExists bool
+}
+
+// ParseConditionOperator parses a condition operator key. It returns an error
+// if the key has an unknown qualifier or is otherwise malformed. It does not
+// check that the base operator name is one IAM defines.
+func ParseConditionOperator(key string) (ConditionOperator, error) {
+	op := ConditionOperator{}
+	name := key
+	if i := strings.Index(key, ":"); i >= 0 {
+		op.Qualifier = key[:i]
+		name = key[i+1:]
+		if op.Qualifier != QualifierForAllValues && op.Qualifier != QualifierForAnyValue {
+			return ConditionOperator{}, fmt.Errorf("%s %q: unknown qualifier %q", ErrorInvalidConditionOperator, key, op.Qualifier)
+		}
+	}
+	if name != OperatorSuffixIfExists && strings.HasSuffix(name, OperatorSuffixIfExists) {
+		op.IfExists = true
+		name = strings.TrimSuffix(name, OperatorSuffixIfExists)
+	}
+	if name == "" || strings.ContainsAny(name, ": ") {
+		return ConditionOperator{}, fmt.Errorf("%s %q", ErrorInvalidConditionOperator, key)
+	}
+	if name == OperatorNull && (op.IfExists || op.Qualifier != "") {
+		return ConditionOperator{}, fmt.Errorf("%s %q: %s cannot be qualified", ErrorInvalidConditionOperator, key, OperatorNull)
+	}
+	op.Name = name
+	return op, nil
+}
+
+// String returns the operator key as used in a Condition element.
+func (o ConditionOperator) String() string {
+	resp := o.Name
+	if o.IfExists {
+		resp += OperatorSuffixIfExists
+	}
+	if o.Qualifier != "" {
+		resp = o.Qualifier + ":" + resp
+	}
+	return resp
+}
+
+var knownOperators = map[string]bool{
+	OperatorStringEquals:              true,
+	OperatorStringNotEquals:           true,
+	OperatorStringEqualsIgnoreCase:    true,
+	OperatorStringNotEqualsIgnoreCase: true,
+	OperatorStringLike:                true,
+	OperatorStringNotLike:             true,
+	OperatorNumericEquals:             true,
+	OperatorNumericNotEquals:          true,
+	OperatorNumericLessThan:           true,
+	OperatorNumericLessThanEquals:     true,
+	OperatorNumericGreaterThan:        true,
+	OperatorNumericGreaterThanEquals:  true,
+	OperatorDateEquals:                true,
+	OperatorDateNotEquals:             true,
+	OperatorDateLessThan:              true,
+	OperatorDateLessThanEquals:        true,
+	OperatorDateGreaterThan:           true,
+	OperatorDateGreaterThanEquals:     true,
+	OperatorBool:                      true,
+	OperatorBinaryEquals:              true,
+	OperatorIpAddress:                 true,
+	OperatorNotIpAddress:              true,
+	OperatorArnEquals:                 true,
+	OperatorArnLike:                   true,
+	OperatorArnNotEquals:              true,
+	OperatorArnNotLike:                true,
+	OperatorNull:                      true,
+}
+
+// IsKnown returns true if the base operator is one IAM defines.
+func (o ConditionOperator) IsKnown() bool {
+	return knownOperators[o.Name]
+}
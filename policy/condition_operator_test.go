@@ -0,0 +1,76 @@
+package policy
+
+import "testing"
+
+func TestParseConditionOperator(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    ConditionOperator
+		wantErr string
+	}{
+		{
+			name: "Plain",
+			in:   "StringEquals",
+			want: ConditionOperator{Name: OperatorStringEquals},
+		},
+		{
+			name: "IfExists",
+			in:   "ArnNotEqualsIfExists",
+			want: ConditionOperator{Name: OperatorArnNotEquals, IfExists: true},
+		},
+		{
+			name: "ForAllValues",
+			in:   "ForAllValues:StringNotEquals",
+			want: ConditionOperator{Qualifier: QualifierForAllValues, Name: OperatorStringNotEquals},
+		},
+		{
+			name: "ForAnyValueIfExists",
+			in:   "ForAnyValue:StringLikeIfExists",
+			want: ConditionOperator{Qualifier: QualifierForAnyValue, Name: OperatorStringLike, IfExists: true},
+		},
+		{
+			name:    "UnknownQualifier",
+			in:      "ForSomeValues:StringLike",
+			wantErr: `invalid condition operator "ForSomeValues:StringLike": unknown qualifier "ForSomeValues"`,
+		},
+		{
+			name:    "Empty",
+			in:      "",
+			wantErr: `invalid condition operator ""`,
+		},
+		{
+			name:    "EmptyName",
+			in:      "ForAnyValue:",
+			wantErr: `invalid condition operator "ForAnyValue:"`,
+		},
+		{
+			name:    "QualifiedNull",
+			in:      "ForAllValues:Null",
+			wantErr: `invalid condition operator "ForAllValues:Null": Null cannot be qualified`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseConditionOperator(tc.in)
+			if err != nil {
+				if tc.wantErr == "" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err.Error() != tc.wantErr {
+					t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if tc.wantErr != "" {
+				t.Fatalf("expected error, got nil")
+			}
+			if got != tc.want {
+				t.Errorf("got '%+v', want '%+v'", got, tc.want)
+			}
+			if got.String() != tc.in {
+				t.Errorf("got '%s', want '%s'", got.String(), tc.in)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func newTestCondition() Condition {
+	return Condition{
+		"StringEquals": {
+			"s3:x-amz-acl":          NewConditionValueString(true, "bucket-owner-full-control"),
+			"aws:PrincipalTag/team": NewConditionValueString(true, "platform"),
+		},
+		"ForAnyValue:StringLike": {
+			"aws:TagKeys": NewConditionValueString(false, "team-*"),
+		},
+		"StringNotEqualsIfExists": {
+			"aws:principaltag/team": NewConditionValueString(true, "security"),
+		},
+	}
+}
+
+func TestConditionEntries(t *testing.T) {
+	got, err := newTestCondition().Entries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		operator string
+		key      string
+	}{
+		{"ForAnyValue:StringLike", "aws:TagKeys"},
+		{"StringEquals", "aws:PrincipalTag/team"},
+		{"StringEquals", "s3:x-amz-acl"},
+		{"StringNotEqualsIfExists", "aws:principaltag/team"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got '%d', want '%d'", len(got), len(want))
+	}
+	for i, entry := range got {
+		if entry.Operator.String() != want[i].operator || entry.Key != want[i].key {
+			t.Errorf("got '%s %s', want '%s %s'", entry.Operator, entry.Key, want[i].operator, want[i].key)
+		}
+	}
+	if got[0].Operator.Qualifier != QualifierForAnyValue || got[0].Operator.Name != OperatorStringLike {
+		t.Errorf("got '%+v', want parsed ForAnyValue:StringLike", got[0].Operator)
+	}
+}
+
+func TestConditionEntriesInvalidOperator(t *testing.T) {
+	c := Condition{"ForSomeValues:StringLike": {"aws:TagKeys": NewConditionValueString(true, "a")}}
+	_, err := c.Entries()
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestConditionLookup(t *testing.T) {
+	got := newTestCondition().Lookup("AWS:PrincipalTag/Team")
+	want := []string{"StringEquals", "StringNotEqualsIfExists"}
+	if len(got) != len(want) {
+		t.Fatalf("got '%d', want '%d'", len(got), len(want))
+	}
+	for i, entry := range got {
+		if entry.Operator.String() != want[i] {
+			t.Errorf("got '%s', want '%s'", entry.Operator, want[i])
+		}
+	}
+
+	value, ok := newTestCondition().Get("StringEquals", "S3:X-AMZ-ACL")
+	if !ok {
+		t.Fatalf("expected value, got none")
+	}
+	strs, _, _ := value.Values()
+	if len(strs) != 1 || strs[0] != "bucket-owner-full-control" {
+		t.Errorf("got '%v', want '%s'", strs, "bucket-owner-full-control")
+	}
+	if _, ok := newTestCondition().Get("StringLike", "s3:x-amz-acl"); ok {
+		t.Errorf("expected no value, got one")
+	}
+}
+
+func TestConditionAddRemove(t *testing.T) {
+	c := Condition{}
+	cases := []struct {
+		name     string
+		operator string
+		key      string
+		wantErr  string
+	}{
+		{name: "Valid", operator: "StringEquals", key: "aws:SourceAccount"},
+		{name: "Qualified", operator: "ForAllValues:StringEquals", key: "aws:TagKeys"},
+		{name: "Typo", operator: "StringEqual", key: "aws:SourceAccount", wantErr: `unknown condition operator "StringEqual"`},
+		{name: "Malformed", operator: "ForEach:StringEquals", key: "aws:TagKeys", wantErr: `invalid condition operator "ForEach:StringEquals": unknown qualifier "ForEach"`},
+		{name: "EmptyKey", operator: "StringEquals", key: "", wantErr: `StringEquals condition key is empty`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := c.Add(tc.operator, tc.key, NewConditionValueString(true, "111122223333"))
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if err.Error() != tc.wantErr {
+					t.Errorf("got '%s', want '%s'", err.Error(), tc.wantErr)
+				}
+			}
+		})
+	}
+
+	// Adding a key that differs only in case replaces the existing value
+	if err := c.Add("StringEquals", "AWS:SourceAccount", NewConditionValueString(true, "444455556666")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"ForAllValues:StringEquals":{"aws:TagKeys":"111122223333"},"StringEquals":{"aws:SourceAccount":"444455556666"}}`
+	if string(b) != want {
+		t.Errorf("got '%s', want '%s'", string(b), want)
+	}
+
+	if !c.Remove("StringEquals", "aws:sourceaccount") {
+		t.Errorf("expected removal")
+	}
+	if c.Remove("StringEquals", "aws:sourceaccount") {
+		t.Errorf("expected no removal")
+	}
+	if len(c.Operators()) != 1 || c.Operators()[0] != "ForAllValues:StringEquals" {
+		t.Errorf("got '%v', want '%v'", c.Operators(), []string{"ForAllValues:StringEquals"})
+	}
+}
+
+func TestConditionAddNil(t *testing.T) {
+	var s Statement
+	if err := s.Condition.Add("StringEquals", "aws:username", NewConditionValueString(true, "alice")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := json.Marshal(s.Condition)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"StringEquals":{"aws:username":"alice"}}`
+	if string(b) != want {
+		t.Errorf("got '%s', want '%s'", string(b), want)
+	}
+
+	var c Condition
+	if c.Remove("StringEquals", "aws:username") {
+		t.Errorf("expected no removal")
+	}
+}
+
+func TestConditionJSONCompatibility(t *testing.T) {
+	in := `{"ForAnyValue:StringLike":{"aws:TagKeys":["team-*"]},"StringEquals":{"aws:PrincipalTag/team":"platform","s3:x-amz-acl":"bucket-owner-full-control"},"StringNotEqualsIfExists":{"aws:principaltag/team":"security"}}`
+
+	var typed Condition
+	if err := json.Unmarshal([]byte(in), &typed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var untyped map[string]map[string]*ConditionValue
+	if err := json.Unmarshal([]byte(in), &untyped); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	typedOut, err := json.Marshal(typed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	untypedOut, err := json.Marshal(untyped)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(typedOut) != in || string(untypedOut) != in {
+		t.Errorf("got '%s' and '%s', want '%s'", typedOut, untypedOut, in)
+	}
+
+	// Plain nested maps can still be assigned to a Statement's Condition
+	s := Statement{Effect: EffectAllow, Condition: untyped}
+	if len(s.Condition.Operators()) != 3 {
+		t.Errorf("got '%d', want '%d'", len(s.Condition.Operators()), 3)
+	}
+}
@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
 )
 
 const (
 	ErrorInvalidConditionValueSlice = "field not slice of string, bool or float64"
 	ErrorInvalidConditionValue      = "field neither slice of string, bool, or float64 or string, bool or float64"
+	ErrorNotInteger                 = "number is not an integer"
 )
 
 // NewConditionValueString creates a new ConditionValue. If singular is true and
@@ -49,9 +55,56 @@ type ConditionValue struct {
 	strValues  []string
 	boolValues []bool
 	numValues  []float64
+	// numText is the JSON text of each decoded number in numValues, by index.
+	// Numbers added with AddFloat have no text and are formatted from
+	// numValues.
+	numText []string
+	// kinds is the kind of each element of a decoded array, in order. The
+	// elements of each kind are stored in strValues, boolValues and
+	// numValues.
+	kinds []ConditionElementKind
+	// intrinsics holds the CloudFormation intrinsic functions decoded with
+	// AllowIntrinsics, in order. Their positions are recorded in kinds.
+	intrinsics []*Intrinsic
 	singular   bool
 }
 
+// ConditionElementKind is the JSON type of a ConditionElement.
+type ConditionElementKind int
+
+const (
+	ConditionElementString ConditionElementKind = iota
+	ConditionElementBool
+	ConditionElementNumber
+	ConditionElementIntrinsic
+)
+
+// ConditionElement is a single element of a ConditionValue. Only the field
+// matching Kind is set.
+type ConditionElement struct {
+	Kind   ConditionElementKind
+	String string
+	Bool   bool
+	Number json.Number
+	// Intrinsic is set for CloudFormation intrinsic functions, which are only
+	// decoded by Unmarshal with AllowIntrinsics.
+	Intrinsic *Intrinsic
+}
+
+// MarshalJSON encodes the element as a JSON string, bool or number.
+func (e ConditionElement) MarshalJSON() ([]byte, error) {
+	switch e.Kind {
+	case ConditionElementBool:
+		return json.Marshal(e.Bool)
+	case ConditionElementNumber:
+		return json.Marshal(e.Number)
+	case ConditionElementIntrinsic:
+		return e.Intrinsic.MarshalJSON()
+	default:
+		return marshalValue(e.String)
+	}
+}
+
 // AddStrings adds a slice of strings to the ConditionValue. If the
 // ConditionValue already has bools or floats, an error is returned.
 func (c *ConditionValue) AddString(values ...string) error {
@@ -61,6 +114,7 @@ func (c *ConditionValue) AddString(values ...string) error {
 		return errors.New("Cannot add strings, ConditionValue has existing floats")
 	}
 	c.strValues = append(c.strValues, values...)
+	c.appendKinds(ConditionElementString, len(values))
 	if len(c.strValues) > 1 {
 		c.singular = false
 	}
@@ -76,6 +130,7 @@ func (c *ConditionValue) AddBool(values ...bool) error {
 		return errors.New("Cannot add bools, ConditionValue has existing floats")
 	}
 	c.boolValues = append(c.boolValues, values...)
+	c.appendKinds(ConditionElementBool, len(values))
 	if len(c.boolValues) > 1 {
 		c.singular = false
 	}
@@ -91,18 +146,41 @@ func (c *ConditionValue) AddFloat(values ...float64) error {
 		return errors.New("Cannot add floats, ConditionValue has existing bools")
 	}
 	c.numValues = append(c.numValues, values...)
+	c.appendKinds(ConditionElementNumber, len(values))
 	if len(c.numValues) > 1 {
 		c.singular = false
 	}
 	return nil
 }
 
+// appendKinds records the kind of n added elements if the element order is
+// being kept.
+func (c *ConditionValue) appendKinds(kind ConditionElementKind, n int) {
+	if c.kinds == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		c.kinds = append(c.kinds, kind)
+	}
+}
+
 func (c *ConditionValue) UnmarshalJSON(data []byte) error {
+	// Check the syntax first to report errors like json.Unmarshal.
+	err := json.Unmarshal(data, &json.RawMessage{})
+	if err != nil {
+		return err
+	}
 	var tmp interface{}
-	err := json.Unmarshal(data, &tmp)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&tmp)
 	if err != nil {
 		return err
 	}
+	// Clear any value from a previous decode, including the number text
+	// and the element order of a mixed array, as every field is derived
+	// from the new JSON value.
+	*c = ConditionValue{}
 	theString, ok := tmp.(string)
 	if ok {
 		c.strValues = []string{theString}
@@ -115,9 +193,14 @@ func (c *ConditionValue) UnmarshalJSON(data []byte) error {
 		c.singular = true
 		return nil
 	}
-	theFloat, ok := tmp.(float64)
+	theNumber, ok := tmp.(json.Number)
 	if ok {
+		theFloat, err := parseNumber(theNumber)
+		if err != nil {
+			return err
+		}
 		c.numValues = []float64{theFloat}
+		c.numText = []string{theNumber.String()}
 		c.singular = true
 		return nil
 	}
@@ -127,14 +210,24 @@ func (c *ConditionValue) UnmarshalJSON(data []byte) error {
 		strValues := []string{}
 		boolValues := []bool{}
 		numValues := []float64{}
+		numText := []string{}
+		kinds := []ConditionElementKind{}
 		for _, item := range slice {
 			switch item.(type) {
 			case string:
 				strValues = append(strValues, item.(string))
+				kinds = append(kinds, ConditionElementString)
 			case bool:
 				boolValues = append(boolValues, item.(bool))
-			case float64: // all numbers are float64
-				numValues = append(numValues, item.(float64))
+				kinds = append(kinds, ConditionElementBool)
+			case json.Number:
+				theFloat, err := parseNumber(item.(json.Number))
+				if err != nil {
+					return err
+				}
+				numValues = append(numValues, theFloat)
+				numText = append(numText, item.(json.Number).String())
+				kinds = append(kinds, ConditionElementNumber)
 			default:
 				return errors.New(ErrorInvalidConditionValueSlice)
 			}
@@ -143,17 +236,42 @@ func (c *ConditionValue) UnmarshalJSON(data []byte) error {
 		c.strValues = strValues
 		c.boolValues = boolValues
 		c.numValues = numValues
+		c.numText = numText
+		c.kinds = kinds
 		return nil
 	}
 
 	return errors.New(ErrorInvalidConditionValue)
 }
 
+// parseNumber converts a JSON number to a float64, failing like
+// json.Unmarshal for numbers out of range.
+func parseNumber(n json.Number) (float64, error) {
+	f, err := n.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("json: cannot unmarshal number %s into Go value of type float64", n)
+	}
+	return f, nil
+}
+
 func (c *ConditionValue) MarshalJSON() ([]byte, error) {
 	buf := bytes.Buffer{}
 	enc := json.NewEncoder(&buf)
 	enc.SetEscapeHTML(false)
 	var err error
+	if len(c.intrinsics) > 0 {
+		elements := c.Elements()
+		if c.singular && len(elements) == 1 {
+			err = enc.Encode(elements[0])
+		} else {
+			err = enc.Encode(elements)
+		}
+		goto eoCV
+	}
+	if c.IsMixed() {
+		err = enc.Encode(c.Elements())
+		goto eoCV
+	}
 	if c.singular {
 		if len(c.strValues) > 0 {
 			err = enc.Encode(c.strValues[0])
@@ -164,7 +282,7 @@ func (c *ConditionValue) MarshalJSON() ([]byte, error) {
 			goto eoCV
 		}
 		if len(c.numValues) > 0 {
-			err = enc.Encode(c.numValues[0])
+			err = enc.Encode(c.number(0))
 			goto eoCV
 		}
 	}
@@ -176,6 +294,10 @@ func (c *ConditionValue) MarshalJSON() ([]byte, error) {
 		err = enc.Encode(c.boolValues)
 		goto eoCV
 	}
+	if len(c.numText) > 0 {
+		err = enc.Encode(c.Numbers())
+		goto eoCV
+	}
 	err = enc.Encode(c.numValues)
 
 eoCV:
@@ -187,8 +309,177 @@ func (c *ConditionValue) Values() ([]string, []bool, []float64) {
 	return c.strValues, c.boolValues, c.numValues
 }
 
+// Elements returns the elements of the ConditionValue. Elements of a decoded
+// array are returned in their original order, even if they have different
+// types. Otherwise strings are returned first, then bools, then numbers.
+func (c *ConditionValue) Elements() []ConditionElement {
+	total := len(c.strValues) + len(c.boolValues) + len(c.numValues) + len(c.intrinsics)
+	kinds := c.kinds
+	if len(kinds) != total {
+		kinds = make([]ConditionElementKind, 0, total)
+		for i := 0; i < len(c.strValues); i++ {
+			kinds = append(kinds, ConditionElementString)
+		}
+		for i := 0; i < len(c.boolValues); i++ {
+			kinds = append(kinds, ConditionElementBool)
+		}
+		for i := 0; i < len(c.numValues); i++ {
+			kinds = append(kinds, ConditionElementNumber)
+		}
+		for i := 0; i < len(c.intrinsics); i++ {
+			kinds = append(kinds, ConditionElementIntrinsic)
+		}
+	}
+	resp := make([]ConditionElement, 0, total)
+	var s, b, n, in int
+	for _, kind := range kinds {
+		e := ConditionElement{Kind: kind}
+		switch kind {
+		case ConditionElementString:
+			e.String = c.strValues[s]
+			s++
+		case ConditionElementBool:
+			e.Bool = c.boolValues[b]
+			b++
+		case ConditionElementNumber:
+			e.Number = c.number(n)
+			n++
+		case ConditionElementIntrinsic:
+			e.Intrinsic = c.intrinsics[in]
+			in++
+		}
+		resp = append(resp, e)
+	}
+	return resp
+}
+
+// setElements replaces the values of the ConditionValue with elements, keeping
+// their order.
+func (c *ConditionValue) setElements(elements []ConditionElement) {
+	c.strValues = []string{}
+	c.boolValues = []bool{}
+	c.numValues = []float64{}
+	c.numText = []string{}
+	c.intrinsics = nil
+	c.kinds = []ConditionElementKind{}
+	for _, e := range elements {
+		switch e.Kind {
+		case ConditionElementString:
+			c.strValues = append(c.strValues, e.String)
+		case ConditionElementBool:
+			c.boolValues = append(c.boolValues, e.Bool)
+		case ConditionElementNumber:
+			f, _ := e.Number.Float64()
+			c.numValues = append(c.numValues, f)
+			c.numText = append(c.numText, e.Number.String())
+		case ConditionElementIntrinsic:
+			c.intrinsics = append(c.intrinsics, e.Intrinsic)
+		}
+		c.kinds = append(c.kinds, e.Kind)
+	}
+}
+
+// resolveIntrinsics replaces intrinsic elements with their resolved strings.
+func (c *ConditionValue) resolveIntrinsics(path string, params map[string]string) error {
+	if c == nil || len(c.intrinsics) == 0 {
+		return nil
+	}
+	elements := c.Elements()
+	for i, e := range elements {
+		if e.Kind != ConditionElementIntrinsic {
+			continue
+		}
+		value, err := e.Intrinsic.Resolve(params)
+		if err != nil {
+			if !c.IsSingular() {
+				path = jsonpointer.Append(path, strconv.Itoa(i))
+			}
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		elements[i] = ConditionElement{Kind: ConditionElementString, String: value}
+	}
+	c.setElements(elements)
+	return nil
+}
+
+// IsMixed returns true if the ConditionValue holds elements of more than one
+// type, such as ["a", true, 3]. Intrinsic functions are not counted as a
+// type.
+func (c *ConditionValue) IsMixed() bool {
+	types := 0
+	for _, n := range []int{len(c.strValues), len(c.boolValues), len(c.numValues)} {
+		if n > 0 {
+			types++
+		}
+	}
+	return types > 1
+}
+
+// number returns the numeric value at index i as a json.Number, using its
+// decoded text if it has one.
+func (c *ConditionValue) number(i int) json.Number {
+	if i < len(c.numText) {
+		return json.Number(c.numText[i])
+	}
+	b, err := json.Marshal(c.numValues[i])
+	if err != nil {
+		// NaN and infinities have no JSON form.
+		return json.Number(strconv.FormatFloat(c.numValues[i], 'g', -1, 64))
+	}
+	return json.Number(b)
+}
+
+// Numbers returns the numeric values of the ConditionValue as they were
+// written in the decoded JSON, such as "10.0" or "12345678901234567890".
+// Numbers added with AddFloat are formatted as encoding/json would.
+func (c *ConditionValue) Numbers() []json.Number {
+	if len(c.numValues) == 0 {
+		return nil
+	}
+	resp := make([]json.Number, len(c.numValues))
+	for i := range c.numValues {
+		resp[i] = c.number(i)
+	}
+	return resp
+}
+
+// Float64s returns the numeric values of the ConditionValue as float64.
+// Numbers that cannot be represented exactly are rounded.
+func (c *ConditionValue) Float64s() []float64 {
+	return c.numValues
+}
+
+// Int64s returns the numeric values of the ConditionValue as int64. Integers
+// are parsed from their decoded text, so large values keep their precision.
+// An error is returned if a value is not an integer or is out of range.
+func (c *ConditionValue) Int64s() ([]int64, error) {
+	if len(c.numValues) == 0 {
+		return nil, nil
+	}
+	resp := make([]int64, len(c.numValues))
+	for i, f := range c.numValues {
+		n := c.number(i)
+		v, err := strconv.ParseInt(n.String(), 10, 64)
+		if err == nil {
+			resp[i] = v
+			continue
+		}
+		// Integers written with a fraction or exponent, such as 10.0 or 1e3.
+		if f != math.Trunc(f) || math.Abs(f) >= 1<<63 {
+			return nil, fmt.Errorf("%s: %s", ErrorNotInteger, n)
+		}
+		resp[i] = int64(f)
+	}
+	return resp, nil
+}
+
 // IsSingular returns true if the ConditionValue is a singular value and has
 // zero or one elements.
 func (c *ConditionValue) IsSingular() bool {
 	return c.singular && (len(c.strValues) <= 1) && (len(c.boolValues) <= 1) && (len(c.numValues) <= 1)
 }
+
+// isEmpty returns true if the ConditionValue has no values.
+func (c *ConditionValue) isEmpty() bool {
+	return len(c.strValues) == 0 && len(c.boolValues) == 0 && len(c.numValues) == 0 && len(c.intrinsics) == 0
+}
@@ -1,7 +1,9 @@
 package policy
 
 import (
+	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -96,6 +98,21 @@ func TestConditionValueJSON(t *testing.T) {
 			in:   `[]`,
 			want: `[]`,
 		},
+		{
+			name: "MixedSlice",
+			in:   `["a", true, 3.0, "b", false]`,
+			want: `["a",true,3.0,"b",false]`,
+		},
+		{
+			name: "DecimalNumber",
+			in:   `10.0`,
+			want: `10.0`,
+		},
+		{
+			name: "LargeInteger",
+			in:   `[12345678901234567890, 9007199254740993, 1E3]`,
+			want: `[12345678901234567890,9007199254740993,1E3]`,
+		},
 	}
 
 	for _, tc := range cases {
@@ -325,3 +342,168 @@ func TestConditionValueAdd(t *testing.T) {
 	}
 
 }
+
+func TestConditionValueNumbers(t *testing.T) {
+	cases := []struct {
+		name        string
+		in          *ConditionValue
+		json        string
+		wantNumbers []json.Number
+		wantFloats  []float64
+		wantInts    []int64
+		wantErr     string
+	}{
+		{
+			name:        "Decoded",
+			json:        `[10.0, 9007199254740993, 1e3, -4]`,
+			wantNumbers: []json.Number{"10.0", "9007199254740993", "1e3", "-4"},
+			wantFloats:  []float64{10, 9007199254740992, 1000, -4},
+			wantInts:    []int64{10, 9007199254740993, 1000, -4},
+		},
+		{
+			name:        "Added",
+			in:          NewConditionValueFloat(false, 1.5, 2),
+			wantNumbers: []json.Number{"1.5", "2"},
+			wantFloats:  []float64{1.5, 2},
+			wantErr:     "number is not an integer: 1.5",
+		},
+		{
+			name:        "OutOfRange",
+			json:        `12345678901234567890`,
+			wantNumbers: []json.Number{"12345678901234567890"},
+			wantFloats:  []float64{12345678901234567890},
+			wantErr:     "number is not an integer: 12345678901234567890",
+		},
+		{
+			name: "Strings",
+			in:   NewConditionValueString(true, "10"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cv := tc.in
+			if cv == nil {
+				cv = &ConditionValue{}
+				if err := cv.UnmarshalJSON([]byte(tc.json)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(cv.Numbers(), tc.wantNumbers) {
+				t.Errorf("got numbers %v, want %v", cv.Numbers(), tc.wantNumbers)
+			}
+			if !reflect.DeepEqual(cv.Float64s(), tc.wantFloats) {
+				t.Errorf("got floats %v, want %v", cv.Float64s(), tc.wantFloats)
+			}
+			ints, err := cv.Int64s()
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("got error '%v', want '%s'", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ints, tc.wantInts) {
+				t.Errorf("got ints %v, want %v", ints, tc.wantInts)
+			}
+		})
+	}
+}
+
+func TestConditionValueReuse(t *testing.T) {
+	cases := []struct {
+		name   string
+		first  string
+		second string
+		want   string
+	}{
+		{name: "NumberThenString", first: `1.50`, second: `"a"`, want: `"a"`},
+		{name: "NumbersThenNumber", first: `[1.50, 2e1]`, second: `3`, want: `3`},
+		{name: "NumbersThenBools", first: `[1.50, 2e1]`, second: `[true, false]`, want: `[true,false]`},
+		{name: "StringThenEmpty", first: `"a"`, second: `[]`, want: `[]`},
+		{name: "MixedThenString", first: `["a", 1, true]`, second: `"b"`, want: `"b"`},
+		{name: "MixedThenBool", first: `[1, "a"]`, second: `false`, want: `false`},
+		{name: "MixedThenMixed", first: `["a", 1, true]`, second: `[false, 2, "b"]`, want: `[false,2,"b"]`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cv := &ConditionValue{}
+			if err := json.Unmarshal([]byte(tc.first), cv); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.second), cv); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			b, err := json.Marshal(cv)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(b) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(b), tc.want)
+			}
+		})
+	}
+}
+
+func TestConditionValueElements(t *testing.T) {
+	cases := []struct {
+		name      string
+		in        *ConditionValue
+		json      string
+		want      []ConditionElement
+		wantMixed bool
+	}{
+		{
+			name: "Mixed",
+			json: `["a", true, 3, "<b>"]`,
+			want: []ConditionElement{
+				{Kind: ConditionElementString, String: "a"},
+				{Kind: ConditionElementBool, Bool: true},
+				{Kind: ConditionElementNumber, Number: "3"},
+				{Kind: ConditionElementString, String: "<b>"},
+			},
+			wantMixed: true,
+		},
+		{
+			name: "Singular",
+			json: `1.50`,
+			want: []ConditionElement{
+				{Kind: ConditionElementNumber, Number: "1.50"},
+			},
+		},
+		{
+			name: "Added",
+			in:   NewConditionValueBool(false, true, false),
+			want: []ConditionElement{
+				{Kind: ConditionElementBool, Bool: true},
+				{Kind: ConditionElementBool, Bool: false},
+			},
+		},
+		{
+			name: "Empty",
+			in:   &ConditionValue{},
+			want: []ConditionElement{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cv := tc.in
+			if cv == nil {
+				cv = &ConditionValue{}
+				if err := cv.UnmarshalJSON([]byte(tc.json)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			got := cv.Elements()
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+			if cv.IsMixed() != tc.wantMixed {
+				t.Errorf("got mixed %t, want %t", cv.IsMixed(), tc.wantMixed)
+			}
+		})
+	}
+}
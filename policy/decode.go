@@ -0,0 +1,617 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
+)
+
+const (
+	ErrorUnexpectedType = "unexpected JSON type"
+	ErrorUnknownField   = "unknown field"
+)
+
+// DecodeOption configures how Unmarshal decodes a policy.
+type DecodeOption func(*decoder)
+
+// PreserveKeyOrder makes Unmarshal remember the order of the keys in the
+// policy, its statements, their principals and their conditions, so that
+// marshaling the policy writes the keys back in the same order. Without it,
+// keys are written in the order of the struct fields, and condition operators
+// and keys are sorted.
+func PreserveKeyOrder() DecodeOption {
+	return func(d *decoder) {
+		d.keyOrder = true
+	}
+}
+
+// AllowIntrinsics makes Unmarshal accept CloudFormation intrinsic functions,
+// such as {"Ref": "Bucket"} or {"Fn::Sub": "arn:aws:s3:::${Bucket}/*"}, in
+// place of strings in Action, Resource, Principal and Condition values. The
+// intrinsics are written back unchanged when the policy is marshaled, and can
+// be evaluated with Policy.ResolveIntrinsics.
+func AllowIntrinsics() DecodeOption {
+	return func(d *decoder) {
+		d.intrinsics = true
+	}
+}
+
+// DecodeError is an error returned by Unmarshal. It records where in the
+// document decoding failed.
+type DecodeError struct {
+	// Offset is the byte offset of the value or character that failed to
+	// decode.
+	Offset int64
+	// Line and Column are the 1-based position of Offset. Columns count
+	// characters, not bytes.
+	Line   int
+	Column int
+	// Pointer is the JSON pointer (RFC 6901) of the value that failed to
+	// decode, such as "/Statement/3/Condition/StringEquals/aws:SourceArn". It
+	// is empty for syntax errors and for errors in the document itself.
+	Pointer string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Pointer == "" {
+		return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %v", e.Line, e.Column, e.Pointer, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownFieldMode controls how Unmarshal handles fields that are not part of
+// the policy grammar.
+type UnknownFieldMode int
+
+const (
+	// UnknownFieldsStrict rejects unknown fields in the policy, its
+	// statements and their principals.
+	UnknownFieldsStrict UnknownFieldMode = iota + 1
+	// UnknownFieldsLenient ignores unknown fields, including those in
+	// statements.
+	UnknownFieldsLenient
+	// UnknownFieldsCapture keeps unknown fields in the Extra map of the
+	// Policy, Statement or Principal they appear in, so that they are written
+	// back when the policy is marshaled.
+	UnknownFieldsCapture
+)
+
+// UnknownFields sets how Unmarshal handles unknown fields. Without this
+// option, unknown fields are rejected in statements and ignored elsewhere,
+// like json.Unmarshal. Unknown condition operators are always accepted.
+func UnknownFields(mode UnknownFieldMode) DecodeOption {
+	return func(d *decoder) {
+		d.unknownFields = mode
+	}
+}
+
+// Unmarshal decodes a policy document. Without options it accepts the same
+// documents as json.Unmarshal: unknown fields in statements are rejected and
+// unknown fields elsewhere are ignored. Errors are returned as a
+// *DecodeError.
+func Unmarshal(data []byte, opts ...DecodeOption) (*Policy, error) {
+	d := &decoder{data: data}
+	for _, opt := range opts {
+		opt(d)
+	}
+	n, err := parseNode(data)
+	if err != nil {
+		de := &DecodeError{Err: err}
+		var se *json.SyntaxError
+		if errors.As(err, &se) && se.Offset > 0 {
+			// The offset of a syntax error is just past the offending
+			// character.
+			de.Offset = se.Offset - 1
+		}
+		return nil, de.locate(data)
+	}
+	p, err := d.policy(n)
+	if err != nil {
+		var de *DecodeError
+		if errors.As(err, &de) {
+			return nil, de.locate(data)
+		}
+		return nil, err
+	}
+	p.duplicates = d.duplicates
+	return p, nil
+}
+
+// locate sets the line and column of the error from its offset in data.
+func (e *DecodeError) locate(data []byte) *DecodeError {
+	e.Line, e.Column = position(data, int(e.Offset))
+	return e
+}
+
+// position returns the 1-based line and column of a byte offset in data.
+// Columns count characters, not bytes.
+func position(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
+
+// decoder decodes a policy from a parsed JSON document.
+type decoder struct {
+	data          []byte
+	keyOrder      bool
+	intrinsics    bool
+	unknownFields UnknownFieldMode
+	// duplicates are the repeated keys found in the document.
+	duplicates ValidationErrors
+}
+
+// checkDuplicate records a validation error if name has already been seen in
+// the object at path. Like encoding/json, the decoder keeps the last value of
+// a repeated key, except that the operators of repeated Condition elements are
+// merged.
+func (d *decoder) checkDuplicate(path string, f *field, name string, seen map[string]bool) {
+	if !seen[name] {
+		seen[name] = true
+		return
+	}
+	line, column := position(d.data, f.offset)
+	d.duplicates.add(jsonpointer.Append(path, f.key), ValidationDuplicateKey,
+		"duplicate key %q at line %d, column %d", f.key, line, column)
+}
+
+// unknownField handles a field that is not part of the grammar of the object
+// at path. Unknown fields are rejected by default if reject is true. Captured
+// fields are added to extra, which is allocated as needed.
+func (d *decoder) unknownField(path string, f *field, reject bool, extra *map[string]json.RawMessage) error {
+	switch d.unknownFields {
+	case UnknownFieldsStrict:
+		reject = true
+	case UnknownFieldsLenient:
+		reject = false
+	case UnknownFieldsCapture:
+		if *extra == nil {
+			*extra = map[string]json.RawMessage{}
+		}
+		(*extra)[f.key] = append(json.RawMessage(nil), f.value.raw...)
+		return nil
+	}
+	if !reject {
+		return nil
+	}
+	return &DecodeError{
+		Offset:  int64(f.offset),
+		Pointer: jsonpointer.Append(path, f.key),
+		Err:     fmt.Errorf("%s %q", ErrorUnknownField, f.key),
+	}
+}
+
+// lookupField returns the canonical name of a field, matching the key
+// case-insensitively like encoding/json.
+func lookupField(names []string, key string) (string, bool) {
+	for _, name := range names {
+		if name == key {
+			return name, true
+		}
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// typeError returns the error for a node of an unexpected type.
+func typeError(path string, n *node, want string) error {
+	return &DecodeError{
+		Offset:  int64(n.offset),
+		Pointer: path,
+		Err:     fmt.Errorf("%s: expected %s, got %s", ErrorUnexpectedType, want, n.kindName()),
+	}
+}
+
+// valueError wraps an error from decoding the value of a node.
+func valueError(path string, n *node, err error) error {
+	return &DecodeError{Offset: int64(n.offset), Pointer: path, Err: err}
+}
+
+var policyFields = []string{"Id", "Statement", "Version"}
+
+func (d *decoder) policy(n *node) (*Policy, error) {
+	if n.kind != nodeObject {
+		return nil, typeError("", n, "object")
+	}
+	p := &Policy{}
+	var keys []string
+	seen := map[string]bool{}
+	for _, f := range n.fields {
+		name, ok := lookupField(policyFields, f.key)
+		if !ok {
+			d.checkDuplicate("", f, f.key, seen)
+			if err := d.unknownField("", f, false, &p.Extra); err != nil {
+				return nil, err
+			}
+			if p.Extra != nil {
+				keys = appendKey(keys, f.key)
+			}
+			continue
+		}
+		d.checkDuplicate("", f, name, seen)
+		keys = appendKey(keys, name)
+		path := jsonpointer.Append("", name)
+		var err error
+		switch name {
+		case "Id":
+			p.Id, err = d.string(path, f.value)
+		case "Version":
+			p.Version, err = d.string(path, f.value)
+		case "Statement":
+			p.Statements, err = d.statements(path, f.value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if d.keyOrder {
+		p.keys = keys
+	}
+	return p, nil
+}
+
+func (d *decoder) string(path string, n *node) (string, error) {
+	switch n.kind {
+	case nodeString:
+		return n.str, nil
+	case nodeNull:
+		return "", nil
+	default:
+		return "", typeError(path, n, "string")
+	}
+}
+
+func (d *decoder) statements(path string, n *node) (*StatementOrSlice, error) {
+	switch n.kind {
+	case nodeNull:
+		return nil, nil
+	case nodeObject:
+		s, layout, err := d.statement(path, n)
+		if err != nil {
+			return nil, err
+		}
+		resp := NewSingularStatementOrSlice(s)
+		if d.keyOrder {
+			resp.layouts = []*statementLayout{layout}
+		}
+		return resp, nil
+	case nodeArray:
+		resp := NewStatementOrSlice()
+		resp.values = []Statement{}
+		for i, elem := range n.elems {
+			elemPath := jsonpointer.Append(path, strconv.Itoa(i))
+			if elem.kind == nodeNull {
+				resp.values = append(resp.values, Statement{})
+				resp.layouts = append(resp.layouts, nil)
+				continue
+			}
+			s, layout, err := d.statement(elemPath, elem)
+			if err != nil {
+				return nil, err
+			}
+			resp.values = append(resp.values, s)
+			resp.layouts = append(resp.layouts, layout)
+		}
+		if !d.keyOrder {
+			resp.layouts = nil
+		}
+		return resp, nil
+	default:
+		return nil, typeError(path, n, "object or array")
+	}
+}
+
+var statementFields = []string{
+	"Action",
+	"Condition",
+	"Effect",
+	"NotAction",
+	"NotResource",
+	"Principal",
+	"NotPrincipal",
+	"Resource",
+	"Sid",
+}
+
+func (d *decoder) statement(path string, n *node) (Statement, *statementLayout, error) {
+	s := Statement{}
+	if n.kind != nodeObject {
+		return s, nil, typeError(path, n, "object")
+	}
+	layout := &statementLayout{}
+	seen := map[string]bool{}
+	for _, f := range n.fields {
+		name, ok := lookupField(statementFields, f.key)
+		if !ok {
+			d.checkDuplicate(path, f, f.key, seen)
+			if err := d.unknownField(path, f, true, &s.Extra); err != nil {
+				return s, nil, err
+			}
+			if s.Extra != nil {
+				layout.keys = appendKey(layout.keys, f.key)
+			}
+			continue
+		}
+		d.checkDuplicate(path, f, name, seen)
+		layout.keys = appendKey(layout.keys, name)
+		fieldPath := jsonpointer.Append(path, name)
+		var err error
+		switch name {
+		case "Action":
+			s.Action, err = d.stringOrSlice(fieldPath, f.value)
+		case "NotAction":
+			s.NotAction, err = d.stringOrSlice(fieldPath, f.value)
+		case "Resource":
+			s.Resource, err = d.stringOrSlice(fieldPath, f.value)
+		case "NotResource":
+			s.NotResource, err = d.stringOrSlice(fieldPath, f.value)
+		case "Effect":
+			s.Effect, err = d.string(fieldPath, f.value)
+		case "Sid":
+			s.Sid, err = d.string(fieldPath, f.value)
+		case "Principal":
+			s.Principal, err = d.principal(fieldPath, f.value)
+		case "NotPrincipal":
+			s.NotPrincipal, err = d.principal(fieldPath, f.value)
+		case "Condition":
+			var c Condition
+			var cl *conditionLayout
+			c, cl, err = d.condition(fieldPath, f.value)
+			s.Condition, layout.condition = mergeCondition(s.Condition, layout.condition, c, cl)
+		}
+		if err != nil {
+			return s, nil, err
+		}
+	}
+	if !d.keyOrder {
+		return s, nil, nil
+	}
+	return s, layout, nil
+}
+
+func (d *decoder) stringOrSlice(path string, n *node) (*StringOrSlice, error) {
+	if n.kind == nodeNull {
+		return nil, nil
+	}
+	if d.intrinsics {
+		if s, ok := d.intrinsicStringOrSlice(n); ok {
+			return s, nil
+		}
+	}
+	s := &StringOrSlice{}
+	if err := s.UnmarshalJSON(n.raw); err != nil {
+		return nil, valueError(path, n, err)
+	}
+	return s, nil
+}
+
+// intrinsicStringOrSlice decodes a string or slice that holds intrinsic
+// functions. It returns false if the node holds none, or holds values other
+// than strings and intrinsics.
+func (d *decoder) intrinsicStringOrSlice(n *node) (*StringOrSlice, bool) {
+	if intrinsic := intrinsicNode(n); intrinsic != nil {
+		return &StringOrSlice{
+			values:     []string{intrinsic.String()},
+			singular:   true,
+			intrinsics: []*Intrinsic{intrinsic},
+		}, true
+	}
+	if n.kind != nodeArray {
+		return nil, false
+	}
+	s := &StringOrSlice{values: []string{}, intrinsics: []*Intrinsic{}}
+	found := false
+	for _, elem := range n.elems {
+		switch intrinsic := intrinsicNode(elem); {
+		case intrinsic != nil:
+			s.values = append(s.values, intrinsic.String())
+			s.intrinsics = append(s.intrinsics, intrinsic)
+			found = true
+		case elem.kind == nodeString:
+			s.values = append(s.values, elem.str)
+			s.intrinsics = append(s.intrinsics, nil)
+		default:
+			return nil, false
+		}
+	}
+	return s, found
+}
+
+// intrinsicConditionValue decodes a condition value that holds intrinsic
+// functions. It returns false if the node holds none, or holds values that
+// are not valid condition values.
+func (d *decoder) intrinsicConditionValue(n *node) (*ConditionValue, bool) {
+	if intrinsic := intrinsicNode(n); intrinsic != nil {
+		c := &ConditionValue{singular: true}
+		c.setElements([]ConditionElement{{Kind: ConditionElementIntrinsic, Intrinsic: intrinsic}})
+		return c, true
+	}
+	if n.kind != nodeArray {
+		return nil, false
+	}
+	elements := []ConditionElement{}
+	found := false
+	for _, elem := range n.elems {
+		e := ConditionElement{}
+		switch intrinsic := intrinsicNode(elem); {
+		case intrinsic != nil:
+			e = ConditionElement{Kind: ConditionElementIntrinsic, Intrinsic: intrinsic}
+			found = true
+		case elem.kind == nodeString:
+			e = ConditionElement{Kind: ConditionElementString, String: elem.str}
+		case elem.kind == nodeBool:
+			e = ConditionElement{Kind: ConditionElementBool, Bool: elem.raw[0] == 't'}
+		case elem.kind == nodeNumber:
+			if _, err := parseNumber(json.Number(elem.raw)); err != nil {
+				return nil, false
+			}
+			e = ConditionElement{Kind: ConditionElementNumber, Number: json.Number(elem.raw)}
+		default:
+			return nil, false
+		}
+		elements = append(elements, e)
+	}
+	if !found {
+		return nil, false
+	}
+	c := &ConditionValue{}
+	c.setElements(elements)
+	return c, true
+}
+
+var principalFields = []string{
+	PrincipalKindAWS,
+	PrincipalKindCanonical,
+	PrincipalKindFederated,
+	PrincipalKindService,
+}
+
+func (d *decoder) principal(path string, n *node) (*Principal, error) {
+	switch n.kind {
+	case nodeNull:
+		return nil, nil
+	case nodeString:
+		return &Principal{str: n.str}, nil
+	case nodeObject:
+	default:
+		return nil, typeError(path, n, "string or object")
+	}
+	resp := &Principal{}
+	p := &principal{}
+	seen := map[string]bool{}
+	for _, f := range n.fields {
+		name, ok := lookupField(principalFields, f.key)
+		if !ok {
+			d.checkDuplicate(path, f, f.key, seen)
+			if err := d.unknownField(path, f, false, &resp.Extra); err != nil {
+				return nil, err
+			}
+			if d.keyOrder && resp.Extra != nil {
+				p.keys = appendKey(p.keys, f.key)
+			}
+			continue
+		}
+		d.checkDuplicate(path, f, name, seen)
+		if d.keyOrder {
+			p.keys = appendKey(p.keys, name)
+		}
+		value, err := d.stringOrSlice(jsonpointer.Append(path, name), f.value)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case PrincipalKindAWS:
+			p.AWS = value
+		case PrincipalKindCanonical:
+			p.CanonicalUser = value
+		case PrincipalKindFederated:
+			p.Federated = value
+		case PrincipalKindService:
+			p.Service = value
+		}
+	}
+	resp.principal = p
+	return resp, nil
+}
+
+func (d *decoder) condition(path string, n *node) (Condition, *conditionLayout, error) {
+	switch n.kind {
+	case nodeNull:
+		return nil, nil, nil
+	case nodeObject:
+	default:
+		return nil, nil, typeError(path, n, "object")
+	}
+	c := Condition{}
+	layout := &conditionLayout{keys: map[string][]string{}}
+	seen := map[string]bool{}
+	for _, f := range n.fields {
+		d.checkDuplicate(path, f, f.key, seen)
+		opPath := jsonpointer.Append(path, f.key)
+		layout.operators = appendKey(layout.operators, f.key)
+		switch f.value.kind {
+		case nodeNull:
+			c[f.key] = nil
+			continue
+		case nodeObject:
+		default:
+			return nil, nil, typeError(opPath, f.value, "object")
+		}
+		block := map[string]*ConditionValue{}
+		seenKeys := map[string]bool{}
+		for _, kf := range f.value.fields {
+			// IAM matches condition keys case-insensitively, so keys that
+			// differ only in case are repeated keys.
+			d.checkDuplicate(opPath, kf, strings.ToLower(kf.key), seenKeys)
+			layout.keys[f.key] = appendKey(layout.keys[f.key], kf.key)
+			if kf.value.kind == nodeNull {
+				block[kf.key] = nil
+				continue
+			}
+			if d.intrinsics {
+				if value, ok := d.intrinsicConditionValue(kf.value); ok {
+					block[kf.key] = value
+					continue
+				}
+			}
+			value := &ConditionValue{}
+			if err := value.UnmarshalJSON(kf.value.raw); err != nil {
+				return nil, nil, valueError(jsonpointer.Append(opPath, kf.key), kf.value, err)
+			}
+			block[kf.key] = value
+		}
+		c[f.key] = block
+	}
+	if !d.keyOrder {
+		return c, nil, nil
+	}
+	return c, layout, nil
+}
+
+// mergeCondition merges a repeated Condition element into the previous one,
+// as encoding/json does when decoding into an existing map.
+func mergeCondition(prev Condition, prevLayout *conditionLayout, c Condition, layout *conditionLayout) (Condition, *conditionLayout) {
+	if prev == nil || c == nil {
+		return c, layout
+	}
+	for op, block := range c {
+		prev[op] = block
+	}
+	if prevLayout == nil || layout == nil {
+		return prev, layout
+	}
+	for _, op := range layout.operators {
+		prevLayout.operators = appendKey(prevLayout.operators, op)
+		prevLayout.keys[op] = layout.keys[op]
+	}
+	return prev, prevLayout
+}
+
+// appendKey appends a key to a key order unless it is already present.
+func appendKey(keys []string, key string) []string {
+	for _, k := range keys {
+		if k == key {
+			return keys
+		}
+	}
+	return append(keys, key)
+}
@@ -0,0 +1,299 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshal(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		opts    []DecodeOption
+		want    string
+		wantErr string
+	}{
+		{
+			name: "DefaultOrder",
+			in:   `{"Version":"2012-10-17","Statement":{"Sid":"1","Effect":"Allow","Action":"s3:GetObject","Resource":"*"}}`,
+			want: `{"Statement":{"Action":"s3:GetObject","Effect":"Allow","Resource":"*","Sid":"1"},"Version":"2012-10-17"}`,
+		},
+		{
+			name: "PreserveKeyOrder",
+			in:   `{"Version":"2012-10-17","Statement":{"Sid":"1","Effect":"Allow","Action":"s3:GetObject","Resource":"*"}}`,
+			opts: []DecodeOption{PreserveKeyOrder()},
+			want: `{"Version":"2012-10-17","Statement":{"Sid":"1","Effect":"Allow","Action":"s3:GetObject","Resource":"*"}}`,
+		},
+		{
+			name: "PreserveKeyOrderPrincipal",
+			in:   `{"Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com","AWS":["arn:aws:iam::111122223333:root"]},"Action":"sts:AssumeRole"}],"Version":"2012-10-17"}`,
+			opts: []DecodeOption{PreserveKeyOrder()},
+			want: `{"Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com","AWS":["arn:aws:iam::111122223333:root"]},"Action":"sts:AssumeRole"}],"Version":"2012-10-17"}`,
+		},
+		{
+			name: "PreserveKeyOrderCondition",
+			in:   `{"Statement":[{"Effect":"Deny","Action":"s3:*","Resource":"*","Condition":{"StringNotEquals":{"aws:SourceVpc":"vpc-1","aws:PrincipalAccount":"111122223333"},"Bool":{"aws:SecureTransport":"false"}}}],"Version":"2012-10-17"}`,
+			opts: []DecodeOption{PreserveKeyOrder()},
+			want: `{"Statement":[{"Effect":"Deny","Action":"s3:*","Resource":"*","Condition":{"StringNotEquals":{"aws:SourceVpc":"vpc-1","aws:PrincipalAccount":"111122223333"},"Bool":{"aws:SecureTransport":"false"}}}],"Version":"2012-10-17"}`,
+		},
+		{
+			name: "CaseInsensitiveKeys",
+			in:   `{"version":"2012-10-17","statement":[{"effect":"Allow","action":"s3:GetObject"}]}`,
+			opts: []DecodeOption{PreserveKeyOrder()},
+			want: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject"}]}`,
+		},
+		{
+			name:    "UnknownStatementField",
+			in:      `{"Statement":[{"Effect":"Allow","Actions":"s3:GetObject"}]}`,
+			wantErr: `line 1, column 33: /Statement/0/Actions: unknown field "Actions"`,
+		},
+		{
+			name:    "StatementType",
+			in:      `{"Statement":"Allow"}`,
+			wantErr: `line 1, column 14: /Statement: unexpected JSON type: expected object or array, got string`,
+		},
+		{
+			name:    "Syntax",
+			in:      `{"Statement":`,
+			wantErr: `line 1, column 13: unexpected end of JSON input`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Unmarshal([]byte(tc.in), tc.opts...)
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error '%s', got none", tc.wantErr)
+				}
+				if err.Error() != tc.wantErr {
+					t.Errorf("got error '%s', want '%s'", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := json.Marshal(p)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalUnknownFields(t *testing.T) {
+	in := `{"Version":"2012-10-17","Comment":"x","Statement":[{"Sid":"1","Effect":"Allow","Action":"s3:GetObject","Resource":"*","Principal":{"AWS":"*","Future":["a"]},"Future":{"k":1}}]}`
+	cases := []struct {
+		name    string
+		opts    []DecodeOption
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Default",
+			wantErr: `line 1, column 158: /Statement/0/Future: unknown field "Future"`,
+		},
+		{
+			name:    "Strict",
+			opts:    []DecodeOption{UnknownFields(UnknownFieldsStrict)},
+			wantErr: `line 1, column 25: /Comment: unknown field "Comment"`,
+		},
+		{
+			name: "Lenient",
+			opts: []DecodeOption{UnknownFields(UnknownFieldsLenient)},
+			want: `{"Statement":[{"Action":"s3:GetObject","Effect":"Allow","Principal":{"AWS":"*"},"Resource":"*","Sid":"1"}],"Version":"2012-10-17"}`,
+		},
+		{
+			name: "Capture",
+			opts: []DecodeOption{UnknownFields(UnknownFieldsCapture)},
+			want: `{"Statement":[{"Action":"s3:GetObject","Effect":"Allow","Principal":{"AWS":"*","Future":["a"]},"Resource":"*","Sid":"1","Future":{"k":1}}],"Version":"2012-10-17","Comment":"x"}`,
+		},
+		{
+			name: "CapturePreserveKeyOrder",
+			opts: []DecodeOption{UnknownFields(UnknownFieldsCapture), PreserveKeyOrder()},
+			want: in,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Unmarshal([]byte(in), tc.opts...)
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error '%s', got none", tc.wantErr)
+				}
+				if err.Error() != tc.wantErr {
+					t.Errorf("got error '%s', want '%s'", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := json.Marshal(p)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalDecodeError(t *testing.T) {
+	in := `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"},
+    {
+      "Effect": "Deny",
+      "Action": "s3:*",
+      "Resource": "*",
+      "Condition": {
+        "StringEquals": {"aws:SourceArn": {"Ref": "Bucket"}}
+      }
+    }
+  ]
+}`
+	cases := []struct {
+		name string
+		in   string
+		want DecodeError
+	}{
+		{
+			name: "ConditionValue",
+			in:   in,
+			want: DecodeError{
+				Offset:  254,
+				Line:    10,
+				Column:  43,
+				Pointer: "/Statement/1/Condition/StringEquals/aws:SourceArn",
+			},
+		},
+		{
+			name: "MultiByteColumn",
+			in:   "{\"Id\": \"\u00e9\", \"Statement\": {\"Sid\": 1}}",
+			want: DecodeError{
+				Offset:  34,
+				Line:    1,
+				Column:  34,
+				Pointer: "/Statement/Sid",
+			},
+		},
+		{
+			name: "Syntax",
+			in:   "{\n  \"Version\": \"2012-10-17\",\n}",
+			want: DecodeError{
+				Offset: 29,
+				Line:   3,
+				Column: 1,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tc.in))
+			de, ok := err.(*DecodeError)
+			if !ok {
+				t.Fatalf("got error %T '%v', want *DecodeError", err, err)
+			}
+			if de.Offset != tc.want.Offset || de.Line != tc.want.Line || de.Column != tc.want.Column {
+				t.Errorf("got offset %d line %d column %d, want offset %d line %d column %d",
+					de.Offset, de.Line, de.Column, tc.want.Offset, tc.want.Line, tc.want.Column)
+			}
+			if de.Pointer != tc.want.Pointer {
+				t.Errorf("got pointer '%s', want '%s'", de.Pointer, tc.want.Pointer)
+			}
+			if de.Err == nil || errors.Unwrap(de) != de.Err {
+				t.Errorf("got wrapped error '%v', want non-nil", de.Err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalPreserveKeyOrderAddStatement(t *testing.T) {
+	in := `{"Version":"2012-10-17","Statement":[{"Sid":"1","Effect":"Allow","Action":"s3:GetObject"}]}`
+	p, err := Unmarshal([]byte(in), PreserveKeyOrder())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.Statements.Add(Statement{Effect: EffectDeny, Action: NewStringOrSlice(true, "s3:DeleteObject")})
+	got, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"Version":"2012-10-17","Statement":[{"Sid":"1","Effect":"Allow","Action":"s3:GetObject"},{"Action":"s3:DeleteObject","Effect":"Deny"}]}`
+	if string(got) != want {
+		t.Errorf("got '%s', want '%s'", string(got), want)
+	}
+}
+
+func TestUnmarshalMatchesJSONUnmarshal(t *testing.T) {
+	data, err := os.ReadFile("./test_fixtures/valid_bucket_policies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := []json.RawMessage{}
+	if err := json.Unmarshal(data, &policies); err != nil {
+		t.Fatal(err)
+	}
+	for i, raw := range policies {
+		t.Run(fmt.Sprintf("S3 policy %d", i), func(t *testing.T) {
+			want := &Policy{}
+			if err := json.Unmarshal(raw, want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Unmarshal(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantb, _ := json.Marshal(want)
+			gotb, _ := json.Marshal(got)
+			if !bytes.Equal(wantb, gotb) {
+				t.Errorf("Unmarshal differed from json.Unmarshal:\n%s", cmp.Diff(string(wantb), string(gotb)))
+			}
+		})
+	}
+}
+
+func TestUnmarshalPreserveKeyOrderRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("./test_fixtures/valid_bucket_policies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := []json.RawMessage{}
+	if err := json.Unmarshal(data, &policies); err != nil {
+		t.Fatal(err)
+	}
+	for i, raw := range policies {
+		t.Run(fmt.Sprintf("S3 policy %d", i), func(t *testing.T) {
+			p, err := Unmarshal(raw, PreserveKeyOrder())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := marshalValue(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := bytes.Buffer{}
+			if err := json.Compact(&want, raw); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(want.Bytes(), got) {
+				t.Errorf("Serialized policy differed:\n%s", cmp.Diff(want.String(), string(got)))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 /*
 Package policy implements types for [AWS's IAM policy grammar] and supports JSON serialization and deserialization.
-No validation is performed on the policy, so it is possible to create invalid policies.
+No validation is performed when decoding or encoding a policy, so it is possible to create invalid
+policies. Use the Validate method to check a policy for structural errors.
 
 Here is an example that creates a policy document using this package.
 
@@ -32,6 +33,38 @@ Here is an example that creates a policy document using this package.
 		fmt.Println(string(b))
 	}
 
+Policies decoded with encoding/json are written back with their keys in a fixed
+order. To keep the key order of a hand-written document, decode it with
+Unmarshal and the PreserveKeyOrder option:
+
+	p, err := policy.Unmarshal(data, policy.PreserveKeyOrder())
+
+By default unknown fields are rejected in statements and ignored elsewhere. The
+UnknownFields option makes Unmarshal reject them everywhere, ignore them, or
+keep them in the Extra map of the Policy, Statement or Principal so that they
+are written back when the policy is marshaled:
+
+	p, err := policy.Unmarshal(data, policy.UnknownFields(policy.UnknownFieldsCapture))
+
+Policies embedded in CloudFormation templates can use intrinsic functions such
+as {"Ref": "Bucket"} in place of strings. Decode them with the AllowIntrinsics
+option; they are written back unchanged, and Policy.ResolveIntrinsics replaces
+them with values from a parameter map.
+
+Normalize rewrites a policy into a canonical form, with sorted and deduplicated
+values, lowercased actions and expanded account ID principals, so that
+policies that only differ in their encoding can be compared or hashed.
+Equivalent compares two policies by their canonical forms, and Fingerprint
+hashes the canonical form for use as a cache key or to find duplicate
+policies.
+
+The policy types have Clone methods that return deep copies, and Equal methods
+that compare them without reflection. The IgnoreSingular and IgnoreOrder
+options make Equal ignore the singular or array encoding of values and their
+order:
+
+	equal := a.Equal(b, policy.IgnoreSingular(), policy.IgnoreOrder())
+
 [AWS's IAM policy grammar]: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_grammar.html
 */
 package policy
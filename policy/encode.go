@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// statementLayout records the key order of a decoded statement.
+type statementLayout struct {
+	keys      []string
+	condition *conditionLayout
+}
+
+// conditionLayout records the order of the operators of a decoded Condition
+// element, and the order of the keys in each operator's block.
+type conditionLayout struct {
+	operators []string
+	keys      map[string][]string
+}
+
+// member is a member of a JSON object being marshaled.
+type member struct {
+	key   string
+	value interface{}
+	// omit is true if the member is left out of the object, like a field with
+	// the omitempty option.
+	omit bool
+}
+
+// marshalValue encodes a value without escaping HTML characters.
+func marshalValue(v interface{}) ([]byte, error) {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// marshalObject encodes members as a JSON object. Members named in order are
+// written first, in that order, followed by the remaining members in the
+// order given.
+func marshalObject(members []member, order []string) ([]byte, error) {
+	sorted := make([]member, 0, len(members))
+	used := make([]bool, len(members))
+	for _, key := range order {
+		for i, m := range members {
+			if !used[i] && m.key == key {
+				sorted = append(sorted, m)
+				used[i] = true
+			}
+		}
+	}
+	for i, m := range members {
+		if !used[i] {
+			sorted = append(sorted, m)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	first := true
+	for _, m := range sorted {
+		if m.omit {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, err := marshalValue(m.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := marshalValue(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// withExtra appends the members of an Extra map to members, in sorted order.
+// Keys that name a known member are skipped.
+func withExtra(members []member, extra map[string]json.RawMessage) []member {
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		known := false
+		for _, m := range members {
+			if m.key == key {
+				known = true
+				break
+			}
+		}
+		if !known {
+			members = append(members, member{key: key, value: extra[key]})
+		}
+	}
+	return members
+}
+
+// MarshalJSON encodes the policy. A policy decoded with PreserveKeyOrder is
+// written with its keys in the decoded order.
+func (p Policy) MarshalJSON() ([]byte, error) {
+	return marshalObject(withExtra([]member{
+		{key: "Id", value: p.Id, omit: p.Id == ""},
+		{key: "Statement", value: p.Statements},
+		{key: "Version", value: p.Version},
+	}, p.Extra), p.keys)
+}
+
+// MarshalJSON encodes the statement, followed by the fields in Extra.
+func (s Statement) MarshalJSON() ([]byte, error) {
+	return marshalStatement(s, nil)
+}
+
+// marshalStatement encodes a statement with the key order of a layout. The
+// layout may be nil.
+func marshalStatement(s Statement, layout *statementLayout) ([]byte, error) {
+	if layout == nil {
+		layout = &statementLayout{}
+	}
+	var condition interface{} = s.Condition
+	if layout.condition != nil && len(s.Condition) > 0 {
+		c, err := marshalCondition(s.Condition, layout.condition)
+		if err != nil {
+			return nil, err
+		}
+		condition = json.RawMessage(c)
+	}
+	return marshalObject(withExtra([]member{
+		{key: "Action", value: s.Action, omit: s.Action == nil},
+		{key: "Condition", value: condition, omit: len(s.Condition) == 0},
+		{key: "Effect", value: s.Effect},
+		{key: "NotAction", value: s.NotAction, omit: s.NotAction == nil},
+		{key: "NotResource", value: s.NotResource, omit: s.NotResource == nil},
+		{key: "Principal", value: s.Principal, omit: s.Principal == nil},
+		{key: "NotPrincipal", value: s.NotPrincipal, omit: s.NotPrincipal == nil},
+		{key: "Resource", value: s.Resource, omit: s.Resource == nil},
+		{key: "Sid", value: s.Sid, omit: s.Sid == ""},
+	}, s.Extra), layout.keys)
+}
+
+// marshalCondition encodes a Condition element with the operator and key
+// order of a layout. Operators and keys that are not in the layout are
+// written afterwards in sorted order.
+func marshalCondition(c Condition, layout *conditionLayout) ([]byte, error) {
+	members := []member{}
+	for _, op := range c.Operators() {
+		block := ConditionBlock(c[op])
+		if block == nil {
+			members = append(members, member{key: op, value: nil})
+			continue
+		}
+		blockMembers := []member{}
+		for _, key := range block.Keys() {
+			blockMembers = append(blockMembers, member{key: key, value: block[key]})
+		}
+		b, err := marshalObject(blockMembers, layout.keys[op])
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member{key: op, value: json.RawMessage(b)})
+	}
+	return marshalObject(members, layout.operators)
+}
+
+// MarshalJSON encodes the principal. A principal decoded with
+// PreserveKeyOrder is written with its keys in the decoded order.
+func (p *principal) MarshalJSON() ([]byte, error) {
+	return p.marshalJSON(nil)
+}
+
+// marshalJSON encodes the principal followed by the fields in extra.
+func (p *principal) marshalJSON(extra map[string]json.RawMessage) ([]byte, error) {
+	return marshalObject(withExtra([]member{
+		{key: PrincipalKindAWS, value: p.AWS, omit: p.AWS == nil},
+		{key: PrincipalKindCanonical, value: p.CanonicalUser, omit: p.CanonicalUser == nil},
+		{key: PrincipalKindFederated, value: p.Federated, omit: p.Federated == nil},
+		{key: PrincipalKindService, value: p.Service, omit: p.Service == nil},
+	}, extra), p.keys)
+}
@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalExtra(t *testing.T) {
+	principal := NewAWSPrincipal("*")
+	principal.Extra = map[string]json.RawMessage{"Future": json.RawMessage(`"a"`)}
+	cases := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{
+			name: "Statement",
+			in: Statement{
+				Effect: EffectAllow,
+				Action: NewStringOrSlice(true, "s3:GetObject"),
+				Extra: map[string]json.RawMessage{
+					"Zeta":   json.RawMessage(`1`),
+					"Alpha":  json.RawMessage(`{ "a": true }`),
+					"Effect": json.RawMessage(`"Deny"`),
+				},
+			},
+			want: `{"Action":"s3:GetObject","Effect":"Allow","Alpha":{"a":true},"Zeta":1}`,
+		},
+		{
+			name: "Principal",
+			in:   principal,
+			want: `{"AWS":"*","Future":"a"}`,
+		},
+		{
+			name: "PrincipalOnlyExtra",
+			in:   &Principal{Extra: map[string]json.RawMessage{"Future": json.RawMessage(`"a"`)}},
+			want: `{"Future":"a"}`,
+		},
+		{
+			name: "Policy",
+			in: Policy{
+				Version:    VersionLatest,
+				Statements: NewStatementOrSlice(),
+				Extra:      map[string]json.RawMessage{"Comment": json.RawMessage(`"x"`)},
+			},
+			want: `{"Statement":null,"Version":"2012-10-17","Comment":"x"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := json.Marshal(tc.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(got), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// EqualOption configures how Equal compares policies and their elements.
+type EqualOption func(*equalOptions)
+
+type equalOptions struct {
+	ignoreSingular bool
+	ignoreOrder    bool
+}
+
+// IgnoreSingular makes Equal treat a single value and an array holding only
+// that value as equal, such as "s3:GetObject" and ["s3:GetObject"].
+func IgnoreSingular() EqualOption {
+	return func(o *equalOptions) {
+		o.ignoreSingular = true
+	}
+}
+
+// IgnoreOrder makes Equal compare the values of an element, and the statements
+// of a policy, without regard to their order. Repeated values must be
+// repeated the same number of times.
+func IgnoreOrder() EqualOption {
+	return func(o *equalOptions) {
+		o.ignoreOrder = true
+	}
+}
+
+func newEqualOptions(opts []EqualOption) *equalOptions {
+	o := &equalOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Equal returns true if the policies have the same content. Key order
+// recorded by PreserveKeyOrder and duplicate keys found by Unmarshal are not
+// compared. Two nil policies are equal.
+func (p *Policy) Equal(other *Policy, opts ...EqualOption) bool {
+	return p.equal(other, newEqualOptions(opts))
+}
+
+func (p *Policy) equal(other *Policy, o *equalOptions) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.Id == other.Id &&
+		p.Version == other.Version &&
+		p.Statements.equal(other.Statements, o) &&
+		extraEqual(p.Extra, other.Extra)
+}
+
+// Equal returns true if the statements have the same content.
+func (s *Statement) Equal(other *Statement, opts ...EqualOption) bool {
+	return s.equal(other, newEqualOptions(opts))
+}
+
+func (s *Statement) equal(other *Statement, o *equalOptions) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.Sid == other.Sid &&
+		s.Effect == other.Effect &&
+		s.Action.equal(other.Action, o) &&
+		s.NotAction.equal(other.NotAction, o) &&
+		s.Resource.equal(other.Resource, o) &&
+		s.NotResource.equal(other.NotResource, o) &&
+		s.Principal.equal(other.Principal, o) &&
+		s.NotPrincipal.equal(other.NotPrincipal, o) &&
+		conditionEqual(s.Condition, other.Condition, o) &&
+		extraEqual(s.Extra, other.Extra)
+}
+
+// Equal returns true if the StatementOrSlice values hold equal statements.
+func (s *StatementOrSlice) Equal(other *StatementOrSlice, opts ...EqualOption) bool {
+	return s.equal(other, newEqualOptions(opts))
+}
+
+func (s *StatementOrSlice) equal(other *StatementOrSlice, o *equalOptions) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	if len(s.values) != len(other.values) {
+		return false
+	}
+	if !o.ignoreSingular && (s.singular && len(s.values) == 1) != (other.singular && len(other.values) == 1) {
+		return false
+	}
+	if !o.ignoreOrder {
+		for i := range s.values {
+			if !s.values[i].equal(&other.values[i], o) {
+				return false
+			}
+		}
+		return true
+	}
+	// Statement equality is an equivalence relation, so matching each
+	// statement to the first equal unmatched statement finds a pairing if
+	// one exists.
+	matched := make([]bool, len(other.values))
+	for i := range s.values {
+		found := false
+		for j := range other.values {
+			if !matched[j] && s.values[i].equal(&other.values[j], o) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal returns true if the StringOrSlice values hold the same strings and
+// intrinsic functions.
+func (s *StringOrSlice) Equal(other *StringOrSlice, opts ...EqualOption) bool {
+	return s.equal(other, newEqualOptions(opts))
+}
+
+func (s *StringOrSlice) equal(other *StringOrSlice, o *equalOptions) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	if !o.ignoreSingular && s.IsSingular() != other.IsSingular() {
+		return false
+	}
+	return stringsEqual(s.items(), other.items(), o.ignoreOrder)
+}
+
+// items returns the values of the StringOrSlice, with intrinsic functions
+// marked so that they do not equal a string with the same text.
+func (s *StringOrSlice) items() []string {
+	resp := make([]string, len(s.values))
+	for i, v := range s.values {
+		if intrinsic := s.Intrinsic(i); intrinsic != nil {
+			v = "\x00" + intrinsic.String()
+		}
+		resp[i] = v
+	}
+	return resp
+}
+
+// Equal returns true if the principals are the same. The "*" principal does
+// not equal {"AWS": "*"}.
+func (p *Principal) Equal(other *Principal, opts ...EqualOption) bool {
+	return p.equal(other, newEqualOptions(opts))
+}
+
+func (p *Principal) equal(other *Principal, o *equalOptions) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	if p.str != other.str || !extraEqual(p.Extra, other.Extra) {
+		return false
+	}
+	return p.AWS().equal(other.AWS(), o) &&
+		p.CanonicalUser().equal(other.CanonicalUser(), o) &&
+		p.Federated().equal(other.Federated(), o) &&
+		p.Service().equal(other.Service(), o)
+}
+
+// Equal returns true if the ConditionValue values hold the same elements.
+// Numbers are compared by value, so 1 equals 1.0.
+func (c *ConditionValue) Equal(other *ConditionValue, opts ...EqualOption) bool {
+	return c.equal(other, newEqualOptions(opts))
+}
+
+func (c *ConditionValue) equal(other *ConditionValue, o *equalOptions) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	if !o.ignoreSingular && c.IsSingular() != other.IsSingular() {
+		return false
+	}
+	return stringsEqual(c.items(), other.items(), o.ignoreOrder)
+}
+
+// items returns a string for each element of the ConditionValue that is
+// unique to its kind and value.
+func (c *ConditionValue) items() []string {
+	elements := c.Elements()
+	resp := make([]string, len(elements))
+	for i, e := range elements {
+		switch e.Kind {
+		case ConditionElementBool:
+			if e.Bool {
+				resp[i] = "btrue"
+			} else {
+				resp[i] = "bfalse"
+			}
+		case ConditionElementNumber:
+			resp[i] = "n" + e.Number.String()
+			if f, err := e.Number.Float64(); err == nil {
+				b, _ := json.Marshal(f)
+				resp[i] = "n" + string(b)
+			}
+		case ConditionElementIntrinsic:
+			resp[i] = "i" + e.Intrinsic.String()
+		default:
+			resp[i] = "s" + e.String
+		}
+	}
+	return resp
+}
+
+func conditionEqual(c, other Condition, o *equalOptions) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	for operator, block := range c {
+		otherBlock, ok := other[operator]
+		if !ok || len(block) != len(otherBlock) {
+			return false
+		}
+		for key, value := range block {
+			otherValue, ok := otherBlock[key]
+			if !ok || !value.equal(otherValue, o) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stringsEqual compares two lists of strings, optionally ignoring their
+// order.
+func stringsEqual(a, b []string, ignoreOrder bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if ignoreOrder {
+		a = append([]string{}, a...)
+		b = append([]string{}, b...)
+		sort.Strings(a)
+		sort.Strings(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// extraEqual compares unknown fields by their compact JSON encoding.
+func extraEqual(a, b map[string]json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		other, ok := b[k]
+		if !ok {
+			return false
+		}
+		var av, bv bytes.Buffer
+		if json.Compact(&av, v) != nil || json.Compact(&bv, other) != nil {
+			if !bytes.Equal(v, other) {
+				return false
+			}
+			continue
+		}
+		if !bytes.Equal(av.Bytes(), bv.Bytes()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"testing"
+)
+
+func TestPolicyEqual(t *testing.T) {
+	cases := []struct {
+		name string
+		a    string
+		b    string
+		opts []EqualOption
+		want bool
+	}{
+		{
+			name: "KeyOrder",
+			a:    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}`,
+			b:    `{"Statement": [{"Resource": "*", "Action": "s3:*", "Effect": "Allow"}], "Version": "2012-10-17"}`,
+			want: true,
+		},
+		{
+			name: "Singular",
+			a:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*"}}`,
+			b:    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:*"], "Resource": "*"}]}`,
+			want: false,
+		},
+		{
+			name: "IgnoreSingular",
+			a:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Condition": {"Bool": {"aws:SecureTransport": true}}}}`,
+			b:    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:*"], "Resource": "*", "Condition": {"Bool": {"aws:SecureTransport": [true]}}}]}`,
+			opts: []EqualOption{IgnoreSingular()},
+			want: true,
+		},
+		{
+			name: "Order",
+			a:    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:GetObject", "s3:PutObject"], "Resource": "*"}]}`,
+			b:    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:PutObject", "s3:GetObject"], "Resource": "*"}]}`,
+			want: false,
+		},
+		{
+			name: "IgnoreOrder",
+			a: `{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"},
+				{"Effect": "Allow", "Principal": {"AWS": ["1", "2"]}, "Action": ["s3:GetObject", "s3:PutObject"], "Resource": "*",
+				 "Condition": {"StringEquals": {"aws:SourceVpc": ["vpc-1", "vpc-2"]}}}]}`,
+			b: `{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Principal": {"AWS": ["2", "1"]}, "Action": ["s3:PutObject", "s3:GetObject"], "Resource": "*",
+				 "Condition": {"StringEquals": {"aws:SourceVpc": ["vpc-2", "vpc-1"]}}},
+				{"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`,
+			opts: []EqualOption{IgnoreOrder()},
+			want: true,
+		},
+		{
+			name: "IgnoreOrderCounts",
+			a:    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:GetObject", "s3:GetObject", "s3:PutObject"], "Resource": "*"}]}`,
+			b:    `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": ["s3:PutObject", "s3:GetObject", "s3:PutObject"], "Resource": "*"}]}`,
+			opts: []EqualOption{IgnoreOrder()},
+			want: false,
+		},
+		{
+			name: "Numbers",
+			a:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Condition": {"NumericLessThan": {"s3:max-keys": 10}}}}`,
+			b:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Condition": {"NumericLessThan": {"s3:max-keys": 10.0}}}}`,
+			want: true,
+		},
+		{
+			name: "ConditionTypes",
+			a:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Condition": {"Bool": {"aws:SecureTransport": true}}}}`,
+			b:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*", "Condition": {"Bool": {"aws:SecureTransport": "true"}}}}`,
+			want: false,
+		},
+		{
+			name: "GlobalPrincipal",
+			a:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": "*"}}`,
+			b:    `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Principal": {"AWS": "*"}, "Action": "s3:*", "Resource": "*"}}`,
+			want: false,
+		},
+		{
+			name: "Sid",
+			a:    `{"Version": "2012-10-17", "Statement": {"Sid": "A", "Effect": "Allow", "Action": "s3:*", "Resource": "*"}}`,
+			b:    `{"Version": "2012-10-17", "Statement": {"Sid": "B", "Effect": "Allow", "Action": "s3:*", "Resource": "*"}}`,
+			opts: []EqualOption{IgnoreOrder(), IgnoreSingular()},
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := Unmarshal([]byte(tc.a), PreserveKeyOrder())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			b, err := Unmarshal([]byte(tc.b), PreserveKeyOrder())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := a.Equal(b, tc.opts...); got != tc.want {
+				t.Errorf("got %t, want %t", got, tc.want)
+			}
+			if got := b.Equal(a, tc.opts...); got != tc.want {
+				t.Errorf("got %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEqualElements(t *testing.T) {
+	if !NewStringOrSlice(true, "a").Equal(NewStringOrSlice(false, "a"), IgnoreSingular()) {
+		t.Errorf("got StringOrSlice not equal, want equal")
+	}
+	if NewStringOrSlice(true, "a").Equal(nil) || !(*StringOrSlice)(nil).Equal(nil) {
+		t.Errorf("got wrong result comparing nil StringOrSlice")
+	}
+	if !NewServicePrincipal("a", "b").Equal(NewServicePrincipal("b", "a"), IgnoreOrder()) {
+		t.Errorf("got Principal not equal, want equal")
+	}
+	if NewServicePrincipal("a").Equal(NewAWSPrincipal("a")) {
+		t.Errorf("got Principal equal, want not equal")
+	}
+	if !NewConditionValueFloat(true, 1).Equal(NewConditionValueFloat(true, 1)) {
+		t.Errorf("got ConditionValue not equal, want equal")
+	}
+	s := Statement{Effect: EffectAllow, Action: NewStringOrSlice(true, "s3:*")}
+	if !s.Equal(s.Clone()) {
+		t.Errorf("got Statement not equal to its clone")
+	}
+}
@@ -19,7 +19,7 @@ func Example_newBucketPolicy() {
 				Principal: policy.NewServicePrincipal("cloudtrail.amazonaws.com"),
 				Action:    policy.NewStringOrSlice(false, "s3:PutObject"),
 				Resource:  policy.NewStringOrSlice(false, "arn:aws:s3:::examplebucket/AWSLogs/123456789012/*"),
-				Condition: map[string]map[string]*policy.ConditionValue{
+				Condition: policy.Condition{
 					"StringEquals": {
 						"s3:x-amz-acl": policy.NewConditionValueString(true, "bucket-owner-full-control"),
 					},
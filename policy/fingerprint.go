@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// FingerprintOption configures which parts of a policy Fingerprint hashes.
+type FingerprintOption func(*fingerprintOptions)
+
+type fingerprintOptions struct {
+	ignoreSid bool
+	ignoreId  bool
+}
+
+// FingerprintIgnoreSid leaves the Sid of each statement out of the
+// fingerprint, so that policies differing only in statement names match.
+func FingerprintIgnoreSid() FingerprintOption {
+	return func(o *fingerprintOptions) {
+		o.ignoreSid = true
+	}
+}
+
+// FingerprintIgnoreId leaves the Id of the policy out of the fingerprint.
+func FingerprintIgnoreId() FingerprintOption {
+	return func(o *fingerprintOptions) {
+		o.ignoreId = true
+	}
+}
+
+// Fingerprint returns a stable content hash of a policy, as the hex encoded
+// SHA-256 of the JSON encoding of its normalized form. Policies that only
+// differ in formatting, key order, value order, or in the other ways removed
+// by Normalize have the same fingerprint. By default the Sid of each
+// statement and the Id of the policy are part of the fingerprint.
+//
+// It returns an error if the policy cannot be marshaled, such as when a
+// condition holds a number with no JSON form.
+func Fingerprint(p *Policy, opts ...FingerprintOption) (string, error) {
+	o := &fingerprintOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	n := Normalize(p)
+	if n != nil && (o.ignoreSid || o.ignoreId) {
+		n = n.Clone()
+		if o.ignoreId {
+			n.Id = ""
+		}
+		if o.ignoreSid && n.Statements != nil {
+			for i := range n.Statements.values {
+				n.Statements.values[i].Sid = ""
+			}
+			// Statements are sorted by their encoding, which includes the
+			// Sid.
+			n = Normalize(n)
+		}
+	}
+	b, err := marshalValue(n)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := `{"Version": "2012-10-17", "Id": "A", "Statement": [
+		{"Sid": "Read", "Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": ["s3:GetObject", "s3:ListBucket"], "Resource": "*"},
+		{"Sid": "Deny", "Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`
+	cases := []struct {
+		name string
+		in   string
+		opts []FingerprintOption
+		same bool
+	}{
+		{
+			name: "Formatting",
+			in: `{
+  "Statement": [
+    {"Resource": ["*"], "Action": "s3:DeleteObject", "Effect": "Deny", "Sid": "Deny"},
+    {"Resource": "*", "Action": ["S3:listBucket", "s3:GetObject"], "Principal": {"AWS": "arn:aws:iam::111122223333:root"}, "Effect": "Allow", "Sid": "Read"}
+  ],
+  "Id": "A",
+  "Version": "2012-10-17"
+}`,
+			same: true,
+		},
+		{
+			name: "Sid",
+			in: `{"Version": "2012-10-17", "Id": "A", "Statement": [
+				{"Sid": "Reader", "Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": ["s3:GetObject", "s3:ListBucket"], "Resource": "*"},
+				{"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`,
+			same: false,
+		},
+		{
+			name: "IgnoreSid",
+			in: `{"Version": "2012-10-17", "Id": "A", "Statement": [
+				{"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"},
+				{"Sid": "Reader", "Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": ["s3:GetObject", "s3:ListBucket"], "Resource": "*"}]}`,
+			opts: []FingerprintOption{FingerprintIgnoreSid()},
+			same: true,
+		},
+		{
+			name: "Id",
+			in: `{"Version": "2012-10-17", "Id": "B", "Statement": [
+				{"Sid": "Read", "Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": ["s3:GetObject", "s3:ListBucket"], "Resource": "*"},
+				{"Sid": "Deny", "Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`,
+			same: false,
+		},
+		{
+			name: "IgnoreId",
+			in: `{"Version": "2012-10-17", "Statement": [
+				{"Sid": "Read", "Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": ["s3:GetObject", "s3:ListBucket"], "Resource": "*"},
+				{"Sid": "Deny", "Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`,
+			opts: []FingerprintOption{FingerprintIgnoreId()},
+			same: true,
+		},
+		{
+			name: "Content",
+			in: `{"Version": "2012-10-17", "Id": "A", "Statement": [
+				{"Sid": "Read", "Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": ["s3:GetObject"], "Resource": "*"},
+				{"Sid": "Deny", "Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`,
+			opts: []FingerprintOption{FingerprintIgnoreId(), FingerprintIgnoreSid()},
+			same: false,
+		},
+	}
+	basePolicy, err := Unmarshal([]byte(base))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Unmarshal([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want, err := Fingerprint(basePolicy, tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := Fingerprint(p, tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got == want) != tc.same {
+				t.Errorf("got '%s', base '%s', want same %t", got, want, tc.same)
+			}
+		})
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	p := &Policy{
+		Version: VersionLatest,
+		Statements: NewStatementOrSlice(Statement{
+			Effect:   EffectAllow,
+			Action:   NewStringOrSlice(true, "s3:GetObject"),
+			Resource: NewStringOrSlice(true, "*"),
+		}),
+	}
+	got, err := Fingerprint(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The fingerprint is used as a cache key, so it must not change between
+	// releases.
+	want := "bcc39201244db8b1858079baf36628be42578ba7eb2972791b4a5ab3e386132f"
+	if got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+	before, _ := p.MarshalJSON()
+	if _, err := Fingerprint(p, FingerprintIgnoreSid(), FingerprintIgnoreId()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	after, _ := p.MarshalJSON()
+	if string(after) != string(before) {
+		t.Errorf("got '%s', want '%s'", string(after), string(before))
+	}
+}
@@ -0,0 +1,301 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
+)
+
+const (
+	IntrinsicRef    = "Ref"
+	IntrinsicSub    = "Fn::Sub"
+	IntrinsicGetAtt = "Fn::GetAtt"
+	IntrinsicJoin   = "Fn::Join"
+
+	ErrorUnsupportedIntrinsic = "unsupported intrinsic function"
+	ErrorInvalidIntrinsic     = "invalid intrinsic function arguments"
+	ErrorUnresolvedReference  = "unresolved reference"
+)
+
+// Intrinsic is a CloudFormation intrinsic function, such as
+// {"Fn::Sub": "arn:aws:s3:::${Bucket}/*"}, used in place of a string in a
+// policy embedded in a template. Intrinsics are only decoded by Unmarshal with
+// the AllowIntrinsics option.
+type Intrinsic struct {
+	// Function is the name of the function, such as "Ref" or "Fn::Sub".
+	Function string
+	// Args is the JSON value of the function's arguments.
+	Args json.RawMessage
+}
+
+// MarshalJSON encodes the intrinsic as a single-key object.
+func (i *Intrinsic) MarshalJSON() ([]byte, error) {
+	args, err := marshalValue(i.Args)
+	if err != nil {
+		return nil, err
+	}
+	name, err := marshalValue(i.Function)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("{%s:%s}", name, args)), nil
+}
+
+// String returns the JSON text of the intrinsic.
+func (i *Intrinsic) String() string {
+	b, err := i.MarshalJSON()
+	if err != nil {
+		return i.Function
+	}
+	return string(b)
+}
+
+// isIntrinsicName returns true if name is Ref or an Fn:: function.
+func isIntrinsicName(name string) bool {
+	return name == IntrinsicRef || strings.HasPrefix(name, "Fn::")
+}
+
+// intrinsicNode returns the intrinsic in a node, or nil if the node is not a
+// single-key object naming an intrinsic function.
+func intrinsicNode(n *node) *Intrinsic {
+	if n.kind != nodeObject || len(n.fields) != 1 || !isIntrinsicName(n.fields[0].key) {
+		return nil
+	}
+	return &Intrinsic{
+		Function: n.fields[0].key,
+		Args:     append(json.RawMessage(nil), n.fields[0].value.raw...),
+	}
+}
+
+// parseIntrinsic decodes an intrinsic nested in the arguments of another.
+func parseIntrinsic(data json.RawMessage) (*Intrinsic, bool) {
+	n, err := parseNode(data)
+	if err != nil {
+		return nil, false
+	}
+	i := intrinsicNode(n)
+	return i, i != nil
+}
+
+// Resolve evaluates the intrinsic to a string. Ref, Fn::Sub, Fn::GetAtt and
+// Fn::Join are supported. References are looked up in params by name, and
+// attributes by "LogicalId.Attribute"; pseudo parameters such as
+// "AWS::AccountId" must be included in params to be resolved.
+func (i *Intrinsic) Resolve(params map[string]string) (string, error) {
+	switch i.Function {
+	case IntrinsicRef:
+		var name string
+		if err := json.Unmarshal(i.Args, &name); err != nil {
+			return "", i.argsError()
+		}
+		return lookupParam(params, name)
+	case IntrinsicGetAtt:
+		var name string
+		if err := json.Unmarshal(i.Args, &name); err == nil {
+			return lookupParam(params, name)
+		}
+		var parts []string
+		if err := json.Unmarshal(i.Args, &parts); err != nil || len(parts) != 2 {
+			return "", i.argsError()
+		}
+		return lookupParam(params, parts[0]+"."+parts[1])
+	case IntrinsicSub:
+		return i.resolveSub(params)
+	case IntrinsicJoin:
+		var args []json.RawMessage
+		if err := json.Unmarshal(i.Args, &args); err != nil || len(args) != 2 {
+			return "", i.argsError()
+		}
+		var delimiter string
+		if err := json.Unmarshal(args[0], &delimiter); err != nil {
+			return "", i.argsError()
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(args[1], &items); err != nil {
+			return "", i.argsError()
+		}
+		values := make([]string, len(items))
+		for j, item := range items {
+			value, err := resolveArg(item, params)
+			if err != nil {
+				return "", err
+			}
+			values[j] = value
+		}
+		return strings.Join(values, delimiter), nil
+	default:
+		return "", fmt.Errorf("%s %q", ErrorUnsupportedIntrinsic, i.Function)
+	}
+}
+
+func (i *Intrinsic) argsError() error {
+	return fmt.Errorf("%s: %s", ErrorInvalidIntrinsic, i)
+}
+
+// resolveSub substitutes the ${Name} variables of an Fn::Sub template, using
+// its variable map first and params second. ${!Name} is written as ${Name}.
+func (i *Intrinsic) resolveSub(params map[string]string) (string, error) {
+	var template string
+	vars := map[string]json.RawMessage{}
+	if err := json.Unmarshal(i.Args, &template); err != nil {
+		var args []json.RawMessage
+		if err := json.Unmarshal(i.Args, &args); err != nil || len(args) != 2 {
+			return "", i.argsError()
+		}
+		if err := json.Unmarshal(args[0], &template); err != nil {
+			return "", i.argsError()
+		}
+		if err := json.Unmarshal(args[1], &vars); err != nil {
+			return "", i.argsError()
+		}
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(template, "${")
+		if start < 0 {
+			b.WriteString(template)
+			return b.String(), nil
+		}
+		end := strings.Index(template[start:], "}")
+		if end < 0 {
+			b.WriteString(template)
+			return b.String(), nil
+		}
+		b.WriteString(template[:start])
+		name := template[start+2 : start+end]
+		template = template[start+end+1:]
+		if strings.HasPrefix(name, "!") {
+			b.WriteString("${" + name[1:] + "}")
+			continue
+		}
+		var value string
+		var err error
+		if raw, ok := vars[name]; ok {
+			value, err = resolveArg(raw, params)
+		} else {
+			value, err = lookupParam(params, name)
+		}
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+}
+
+// resolveArg resolves an argument that is a string or a nested intrinsic.
+func resolveArg(data json.RawMessage, params map[string]string) (string, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s, nil
+	}
+	i, ok := parseIntrinsic(data)
+	if !ok {
+		return "", fmt.Errorf("%s: %s", ErrorInvalidIntrinsic, data)
+	}
+	return i.Resolve(params)
+}
+
+func lookupParam(params map[string]string, name string) (string, error) {
+	value, ok := params[name]
+	if !ok {
+		return "", fmt.Errorf("%s %q", ErrorUnresolvedReference, name)
+	}
+	return value, nil
+}
+
+// ResolveIntrinsics replaces the intrinsic functions in the policy's
+// statements with their values, using Intrinsic.Resolve. The policy is left
+// partially resolved if an intrinsic cannot be resolved.
+func (p *Policy) ResolveIntrinsics(params map[string]string) error {
+	if p.Statements == nil {
+		return nil
+	}
+	for i := range p.Statements.values {
+		s := &p.Statements.values[i]
+		path := p.Statements.Pointer(i)
+		for _, f := range []struct {
+			name  string
+			value *StringOrSlice
+		}{
+			{"Action", s.Action},
+			{"NotAction", s.NotAction},
+			{"Resource", s.Resource},
+			{"NotResource", s.NotResource},
+		} {
+			if err := f.value.resolveIntrinsics(jsonpointer.Append(path, f.name), params); err != nil {
+				return err
+			}
+		}
+		for _, principal := range []struct {
+			name  string
+			value *Principal
+		}{
+			{"Principal", s.Principal},
+			{"NotPrincipal", s.NotPrincipal},
+		} {
+			if principal.value == nil || principal.value.principal == nil {
+				continue
+			}
+			inner := principal.value.principal
+			for _, kind := range []struct {
+				name  string
+				value *StringOrSlice
+			}{
+				{PrincipalKindAWS, inner.AWS},
+				{PrincipalKindCanonical, inner.CanonicalUser},
+				{PrincipalKindFederated, inner.Federated},
+				{PrincipalKindService, inner.Service},
+			} {
+				if err := kind.value.resolveIntrinsics(jsonpointer.Append(path, principal.name, kind.name), params); err != nil {
+					return err
+				}
+			}
+		}
+		for _, op := range s.Condition.Operators() {
+			block := ConditionBlock(s.Condition[op])
+			for _, key := range block.Keys() {
+				if err := block[key].resolveIntrinsics(jsonpointer.Append(path, "Condition", op, key), params); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// HasIntrinsics returns true if any statement in the policy holds an
+// intrinsic function.
+func (p *Policy) HasIntrinsics() bool {
+	if p.Statements == nil {
+		return false
+	}
+	for _, s := range p.Statements.values {
+		for _, value := range []*StringOrSlice{s.Action, s.NotAction, s.Resource, s.NotResource} {
+			if value.HasIntrinsics() {
+				return true
+			}
+		}
+		for _, principal := range []*Principal{s.Principal, s.NotPrincipal} {
+			if principal == nil || principal.principal == nil {
+				continue
+			}
+			inner := principal.principal
+			for _, value := range []*StringOrSlice{inner.AWS, inner.CanonicalUser, inner.Federated, inner.Service} {
+				if value.HasIntrinsics() {
+					return true
+				}
+			}
+		}
+		for _, op := range s.Condition.Operators() {
+			for _, value := range s.Condition[op] {
+				if value != nil && len(value.intrinsics) > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
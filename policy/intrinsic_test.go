@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const intrinsicPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":{"Fn::Sub":"arn:${AWS::Partition}:iam::${AWS::AccountId}:root"}},"Action":["s3:GetObject",{"Ref":"ExtraAction"}],"Resource":{"Fn::Join":["",["arn:aws:s3:::",{"Ref":"Bucket"},"/*"]]},"Condition":{"StringEquals":{"aws:SourceArn":[{"Fn::GetAtt":["Topic","Arn"]},"arn:aws:sns:us-east-1:111122223333:other"],"aws:SourceAccount":{"Ref":"AWS::AccountId"}},"NumericLessThan":{"s3:max-keys":10}}}]}`
+
+func TestUnmarshalIntrinsics(t *testing.T) {
+	_, err := Unmarshal([]byte(intrinsicPolicy))
+	want := "line 1, column 75: /Statement/0/Principal/AWS: " + ErrorInvalidStringOrSlice
+	if err == nil || err.Error() != want {
+		t.Errorf("got error '%v', want '%s'", err, want)
+	}
+
+	p, err := Unmarshal([]byte(intrinsicPolicy), AllowIntrinsics(), PreserveKeyOrder())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !p.HasIntrinsics() {
+		t.Errorf("got no intrinsics, want some")
+	}
+	got, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != intrinsicPolicy {
+		t.Errorf("got '%s', want '%s'", string(got), intrinsicPolicy)
+	}
+
+	action := p.Statements.Values()[0].Action
+	if action.Intrinsic(0) != nil {
+		t.Errorf("got intrinsic %v for a string, want nil", action.Intrinsic(0))
+	}
+	if got := action.Values()[1]; got != `{"Ref":"ExtraAction"}` {
+		t.Errorf("got '%s', want '%s'", got, `{"Ref":"ExtraAction"}`)
+	}
+}
+
+func TestResolveIntrinsics(t *testing.T) {
+	params := map[string]string{
+		"AWS::Partition": "aws",
+		"AWS::AccountId": "111122223333",
+		"ExtraAction":    "s3:PutObject",
+		"Bucket":         "my-bucket",
+		"Topic.Arn":      "arn:aws:sns:us-east-1:111122223333:topic",
+	}
+	p, err := Unmarshal([]byte(intrinsicPolicy), AllowIntrinsics(), PreserveKeyOrder())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.ResolveIntrinsics(params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.HasIntrinsics() {
+		t.Errorf("got intrinsics after resolving, want none")
+	}
+	got, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111122223333:root"},"Action":["s3:GetObject","s3:PutObject"],"Resource":"arn:aws:s3:::my-bucket/*","Condition":{"StringEquals":{"aws:SourceArn":["arn:aws:sns:us-east-1:111122223333:topic","arn:aws:sns:us-east-1:111122223333:other"],"aws:SourceAccount":"111122223333"},"NumericLessThan":{"s3:max-keys":10}}}]}`
+	if string(got) != want {
+		t.Errorf("got '%s', want '%s'", string(got), want)
+	}
+
+	p, err = Unmarshal([]byte(intrinsicPolicy), AllowIntrinsics())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	delete(params, "ExtraAction")
+	err = p.ResolveIntrinsics(params)
+	wantErr := `/Statement/0/Action/1: unresolved reference "ExtraAction"`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("got error '%v', want '%s'", err, wantErr)
+	}
+}
+
+func TestIntrinsicResolve(t *testing.T) {
+	params := map[string]string{
+		"Bucket":      "my-bucket",
+		"Role.Arn":    "arn:aws:iam::111122223333:role/r",
+		"AWS::Region": "us-east-1",
+	}
+	cases := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{name: "Ref", in: `{"Ref":"Bucket"}`, want: "my-bucket"},
+		{name: "GetAttList", in: `{"Fn::GetAtt":["Role","Arn"]}`, want: "arn:aws:iam::111122223333:role/r"},
+		{name: "GetAttString", in: `{"Fn::GetAtt":"Role.Arn"}`, want: "arn:aws:iam::111122223333:role/r"},
+		{name: "Sub", in: `{"Fn::Sub":"arn:aws:s3:::${Bucket}/${!Literal}"}`, want: "arn:aws:s3:::my-bucket/${Literal}"},
+		{name: "SubVariables", in: `{"Fn::Sub":["${Name}-${AWS::Region}",{"Name":{"Ref":"Bucket"}}]}`, want: "my-bucket-us-east-1"},
+		{name: "Join", in: `{"Fn::Join":[":",["a",{"Ref":"Bucket"}]]}`, want: "a:my-bucket"},
+		{name: "Unresolved", in: `{"Ref":"Missing"}`, wantErr: `unresolved reference "Missing"`},
+		{name: "Unsupported", in: `{"Fn::Select":[0,["a"]]}`, wantErr: `unsupported intrinsic function "Fn::Select"`},
+		{name: "InvalidArgs", in: `{"Fn::Join":"a"}`, wantErr: `invalid intrinsic function arguments: {"Fn::Join":"a"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			intrinsic, ok := parseIntrinsic(json.RawMessage(tc.in))
+			if !ok {
+				t.Fatalf("'%s' is not an intrinsic", tc.in)
+			}
+			got, err := intrinsic.Resolve(params)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("got error '%v', want '%s'", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got '%s', want '%s'", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"encoding/json"
+)
+
+// Kinds of JSON nodes.
+const (
+	nodeObject byte = '{'
+	nodeArray  byte = '['
+	nodeString byte = '"'
+	nodeNumber byte = '0'
+	nodeBool   byte = 't'
+	nodeNull   byte = 'n'
+)
+
+// node is a JSON value that keeps the order of object keys and the position
+// of each value in the document.
+type node struct {
+	kind byte
+	// offset and end are the byte offsets of the start and end of the value.
+	offset int
+	end    int
+	// raw is the text of the value.
+	raw []byte
+	// str is the decoded value of a string node.
+	str string
+	// fields are the members of an object node, in document order.
+	fields []*field
+	// elems are the elements of an array node.
+	elems []*node
+}
+
+// field is a member of a JSON object.
+type field struct {
+	key string
+	// offset is the byte offset of the key.
+	offset int
+	value  *node
+}
+
+// kindName returns the JSON type name of the node for error messages.
+func (n *node) kindName() string {
+	switch n.kind {
+	case nodeObject:
+		return "object"
+	case nodeArray:
+		return "array"
+	case nodeString:
+		return "string"
+	case nodeNumber:
+		return "number"
+	case nodeBool:
+		return "bool"
+	default:
+		return "null"
+	}
+}
+
+// parseNode parses a JSON document. The document must be valid JSON.
+func parseNode(data []byte) (*node, error) {
+	if !json.Valid(data) {
+		// Use the standard library to report the syntax error.
+		var v interface{}
+		return nil, json.Unmarshal(data, &v)
+	}
+	p := &nodeParser{data: data}
+	p.skipSpace()
+	return p.value()
+}
+
+type nodeParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *nodeParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// value parses the value at the current position. The input is known to be
+// valid JSON, so the parser does not check the grammar.
+func (p *nodeParser) value() (*node, error) {
+	n := &node{offset: p.pos}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		n.kind = nodeObject
+		p.pos++
+		p.skipSpace()
+		for p.data[p.pos] != '}' {
+			keyOffset := p.pos
+			key, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			p.pos++ // ':'
+			p.skipSpace()
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			n.fields = append(n.fields, &field{key: key, offset: keyOffset, value: value})
+			p.skipSpace()
+			if p.data[p.pos] == ',' {
+				p.pos++
+				p.skipSpace()
+			}
+		}
+		p.pos++
+	case c == '[':
+		n.kind = nodeArray
+		n.elems = []*node{}
+		p.pos++
+		p.skipSpace()
+		for p.data[p.pos] != ']' {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			n.elems = append(n.elems, value)
+			p.skipSpace()
+			if p.data[p.pos] == ',' {
+				p.pos++
+				p.skipSpace()
+			}
+		}
+		p.pos++
+	case c == '"':
+		n.kind = nodeString
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		n.str = s
+	case c == 't':
+		n.kind = nodeBool
+		p.pos += len("true")
+	case c == 'f':
+		n.kind = nodeBool
+		p.pos += len("false")
+	case c == 'n':
+		n.kind = nodeNull
+		p.pos += len("null")
+	default:
+		n.kind = nodeNumber
+		for p.pos < len(p.data) && isNumberByte(p.data[p.pos]) {
+			p.pos++
+		}
+	}
+	n.end = p.pos
+	n.raw = p.data[n.offset:n.end]
+	return n, nil
+}
+
+// string parses the string at the current position.
+func (p *nodeParser) string() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.data[p.pos] != '"' {
+		if p.data[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	p.pos++
+	var s string
+	err := json.Unmarshal(p.data[start:p.pos], &s)
+	return s, err
+}
+
+func isNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
@@ -0,0 +1,43 @@
+package policy
+
+import (
+	"testing"
+)
+
+func TestParseNode(t *testing.T) {
+	in := `{"b": [1, "x\"y", true, null], "a": {"c": -1.5e3}}`
+	n, err := parseNode([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n.kind != nodeObject || len(n.fields) != 2 {
+		t.Fatalf("got kind %s with %d fields, want object with 2 fields", n.kindName(), len(n.fields))
+	}
+	if n.fields[0].key != "b" || n.fields[1].key != "a" {
+		t.Errorf("got keys '%s', '%s', want 'b', 'a'", n.fields[0].key, n.fields[1].key)
+	}
+	if n.fields[1].offset != 31 {
+		t.Errorf("got offset %d, want %d", n.fields[1].offset, 31)
+	}
+
+	elems := n.fields[0].value.elems
+	wantKinds := []string{"number", "string", "bool", "null"}
+	if len(elems) != len(wantKinds) {
+		t.Fatalf("got %d elements, want %d", len(elems), len(wantKinds))
+	}
+	for i, want := range wantKinds {
+		if got := elems[i].kindName(); got != want {
+			t.Errorf("element %d: got '%s', want '%s'", i, got, want)
+		}
+	}
+	if elems[1].str != `x"y` {
+		t.Errorf("got '%s', want '%s'", elems[1].str, `x"y`)
+	}
+	if got := string(n.fields[1].value.fields[0].value.raw); got != "-1.5e3" {
+		t.Errorf("got '%s', want '%s'", got, "-1.5e3")
+	}
+
+	if _, err := parseNode([]byte(`{"a":}`)); err == nil {
+		t.Errorf("expected error for invalid JSON, got none")
+	}
+}
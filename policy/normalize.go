@@ -0,0 +1,202 @@
+package policy
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rootARNRegex matches the root ARN of an account used as a principal, such
+// as "arn:aws:iam::111122223333:root", capturing the account ID.
+var rootARNRegex = regexp.MustCompile(`^arn:[a-z-]+:iam::([0-9]{12}):root$`)
+
+// Normalize returns a copy of the policy in a canonical form, so that
+// policies that only differ in their encoding normalize to the same policy:
+//
+//   - Every StringOrSlice, Principal element and ConditionValue is written as
+//     an array, with its values sorted and duplicates removed.
+//   - Actions are lowercased in full, both the service prefix and the action
+//     name, so "S3:getObject" becomes "s3:getobject". IAM matches the whole
+//     action case-insensitively, so lowercasing only the prefix would leave
+//     equal actions different.
+//   - AWS principals given as the root ARN of an account, such as
+//     "arn:aws:iam::111122223333:root", are written as the account ID
+//     "111122223333", which IAM treats the same. The account ID does not
+//     depend on the partition, so this holds in "aws-cn" and "aws-us-gov".
+//   - Condition keys are lowercased, as IAM matches them case-insensitively,
+//     and the values of keys that differ only in case are merged.
+//   - Statements are sorted by their normalized JSON encoding.
+//
+// The key order recorded by PreserveKeyOrder and the duplicate keys reported
+// by Validate are not kept. Sid, Id and unknown fields captured in Extra are
+// kept as they are. Normalize returns nil for a nil policy.
+func Normalize(p *Policy) *Policy {
+	if p == nil {
+		return nil
+	}
+	resp := &Policy{Id: p.Id, Version: p.Version, Extra: copyExtra(p.Extra)}
+	if p.Statements == nil {
+		return resp
+	}
+	type keyed struct {
+		key       string
+		statement Statement
+	}
+	statements := make([]keyed, 0, len(p.Statements.Values()))
+	for _, s := range p.Statements.Values() {
+		n := normalizeStatement(s)
+		b, _ := n.MarshalJSON()
+		statements = append(statements, keyed{key: string(b), statement: n})
+	}
+	sort.SliceStable(statements, func(i, j int) bool { return statements[i].key < statements[j].key })
+	sorted := make([]Statement, len(statements))
+	for i, s := range statements {
+		sorted[i] = s.statement
+	}
+	resp.Statements = NewStatementOrSlice(sorted...)
+	return resp
+}
+
+// Equivalent returns true if two policies have the same normalized form.
+func Equivalent(a, b *Policy) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ab, aerr := json.Marshal(Normalize(a))
+	bb, berr := json.Marshal(Normalize(b))
+	return aerr == nil && berr == nil && string(ab) == string(bb)
+}
+
+func normalizeStatement(s Statement) Statement {
+	return Statement{
+		Sid:          s.Sid,
+		Effect:       s.Effect,
+		Action:       normalizeStringOrSlice(s.Action, strings.ToLower),
+		NotAction:    normalizeStringOrSlice(s.NotAction, strings.ToLower),
+		Resource:     normalizeStringOrSlice(s.Resource, nil),
+		NotResource:  normalizeStringOrSlice(s.NotResource, nil),
+		Principal:    normalizePrincipal(s.Principal),
+		NotPrincipal: normalizePrincipal(s.NotPrincipal),
+		Condition:    normalizeCondition(s.Condition),
+		Extra:        copyExtra(s.Extra),
+	}
+}
+
+// normalizeStringOrSlice returns the values of s rewritten by fold, sorted and
+// without duplicates. Intrinsic functions are kept with their values.
+func normalizeStringOrSlice(s *StringOrSlice, fold func(string) string) *StringOrSlice {
+	if s == nil {
+		return nil
+	}
+	type item struct {
+		value     string
+		intrinsic *Intrinsic
+	}
+	items := make([]item, 0, len(s.values))
+	for i, v := range s.values {
+		it := item{value: v, intrinsic: s.Intrinsic(i)}
+		if it.intrinsic == nil && fold != nil {
+			it.value = fold(v)
+		}
+		items = append(items, it)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].value < items[j].value })
+	resp := &StringOrSlice{values: []string{}}
+	for i, it := range items {
+		if i > 0 && it.value == items[i-1].value {
+			continue
+		}
+		resp.values = append(resp.values, it.value)
+		if it.intrinsic != nil {
+			for len(resp.intrinsics) < len(resp.values)-1 {
+				resp.intrinsics = append(resp.intrinsics, nil)
+			}
+			resp.intrinsics = append(resp.intrinsics, it.intrinsic)
+		}
+	}
+	return resp
+}
+
+// collapseRootARN rewrites the root ARN of an account to its account ID.
+func collapseRootARN(value string) string {
+	if m := rootARNRegex.FindStringSubmatch(value); m != nil {
+		return m[1]
+	}
+	return value
+}
+
+func normalizePrincipal(p *Principal) *Principal {
+	if p == nil {
+		return nil
+	}
+	resp := &Principal{str: p.str, Extra: copyExtra(p.Extra)}
+	if p.principal != nil {
+		resp.principal = &principal{
+			AWS:           normalizeStringOrSlice(p.principal.AWS, collapseRootARN),
+			CanonicalUser: normalizeStringOrSlice(p.principal.CanonicalUser, nil),
+			Federated:     normalizeStringOrSlice(p.principal.Federated, nil),
+			Service:       normalizeStringOrSlice(p.principal.Service, nil),
+		}
+	}
+	return resp
+}
+
+func normalizeCondition(c Condition) Condition {
+	if c == nil {
+		return nil
+	}
+	resp := Condition{}
+	for _, operator := range c.Operators() {
+		block := c.Block(operator)
+		for _, key := range block.Keys() {
+			lower := strings.ToLower(key)
+			if resp[operator] == nil {
+				resp[operator] = map[string]*ConditionValue{}
+			}
+			var elements []ConditionElement
+			if existing := resp[operator][lower]; existing != nil {
+				elements = existing.Elements()
+			}
+			if block[key] != nil {
+				elements = append(elements, block[key].Elements()...)
+			}
+			resp[operator][lower] = normalizeConditionValue(elements)
+		}
+	}
+	return resp
+}
+
+// normalizeConditionValue returns a ConditionValue holding the elements sorted
+// by kind and then by value, without duplicates.
+func normalizeConditionValue(elements []ConditionElement) *ConditionValue {
+	key := func(e ConditionElement) string {
+		switch e.Kind {
+		case ConditionElementBool:
+			return strconv.FormatBool(e.Bool)
+		case ConditionElementNumber:
+			return e.Number.String()
+		case ConditionElementIntrinsic:
+			return e.Intrinsic.String()
+		default:
+			return e.String
+		}
+	}
+	sort.SliceStable(elements, func(i, j int) bool {
+		if elements[i].Kind != elements[j].Kind {
+			return elements[i].Kind < elements[j].Kind
+		}
+		return key(elements[i]) < key(elements[j])
+	})
+	unique := []ConditionElement{}
+	for i, e := range elements {
+		if i > 0 && e.Kind == elements[i-1].Kind && key(e) == key(elements[i-1]) {
+			continue
+		}
+		unique = append(unique, e)
+	}
+	resp := &ConditionValue{}
+	resp.setElements(unique)
+	return resp
+}
@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "Values",
+			in: `{"Version": "2012-10-17", "Statement": {
+				"Effect": "Allow",
+				"Action": ["S3:getObject", "s3:ListBucket", "s3:GetObject"],
+				"Resource": "arn:aws:s3:::bucket/*"}}`,
+			want: `{"Statement":[{"Action":["s3:getobject","s3:listbucket"],"Effect":"Allow","Resource":["arn:aws:s3:::bucket/*"]}],"Version":"2012-10-17"}`,
+		},
+		{
+			name: "Principals",
+			in: `{"Version": "2012-10-17", "Statement": {
+				"Effect": "Allow",
+				"Principal": {"Service": "ec2.amazonaws.com", "AWS": ["111122223333", "arn:aws:iam::111122223333:root", "arn:aws:iam::444455556666:role/admin"]},
+				"Action": "sts:AssumeRole"}}`,
+			want: `{"Statement":[{"Action":["sts:assumerole"],"Effect":"Allow","Principal":{"AWS":["111122223333","arn:aws:iam::444455556666:role/admin"],"Service":["ec2.amazonaws.com"]}}],"Version":"2012-10-17"}`,
+		},
+		{
+			name: "PrincipalsPartitions",
+			in: `{"Version": "2012-10-17", "Statement": {
+				"Effect": "Allow",
+				"Principal": {"AWS": ["111122223333", "arn:aws-cn:iam::111122223333:root", "arn:aws-us-gov:iam::444455556666:root"]},
+				"Action": "s3:GetObject",
+				"Resource": "arn:aws-cn:s3:::bucket/*"}}`,
+			want: `{"Statement":[{"Action":["s3:getobject"],"Effect":"Allow","Principal":{"AWS":["111122223333","444455556666"]},"Resource":["arn:aws-cn:s3:::bucket/*"]}],"Version":"2012-10-17"}`,
+		},
+		{
+			name: "Conditions",
+			in: `{"Version": "2012-10-17", "Statement": {
+				"Effect": "Allow", "Action": "s3:*", "Resource": "*",
+				"Condition": {
+					"StringEquals": {"aws:SourceVpc": ["vpc-2", "vpc-1"], "AWS:sourcevpc": "vpc-1"},
+					"NumericLessThan": {"s3:max-keys": 10},
+					"Bool": {"aws:SecureTransport": true}}}}`,
+			want: `{"Statement":[{"Action":["s3:*"],"Condition":{"Bool":{"aws:securetransport":[true]},"NumericLessThan":{"s3:max-keys":[10]},"StringEquals":{"aws:sourcevpc":["vpc-1","vpc-2"]}},"Effect":"Allow","Resource":["*"]}],"Version":"2012-10-17"}`,
+		},
+		{
+			name: "Statements",
+			in: `{"Version": "2012-10-17", "Id": "Policy", "Statement": [
+				{"Sid": "B", "Effect": "Deny", "Principal": "*", "NotAction": "s3:GetObject", "Resource": "*"},
+				{"Sid": "A", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			want: `{"Id":"Policy","Statement":[{"Action":["s3:getobject"],"Effect":"Allow","Resource":["*"],"Sid":"A"},{"Effect":"Deny","NotAction":["s3:getobject"],"Principal":"*","Resource":["*"],"Sid":"B"}],"Version":"2012-10-17"}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Unmarshal([]byte(tc.in), PreserveKeyOrder())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			before, _ := json.Marshal(p)
+			got, err := json.Marshal(Normalize(p))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(got), tc.want)
+			}
+			// The input is not modified.
+			after, _ := json.Marshal(p)
+			if string(after) != string(before) {
+				t.Errorf("got '%s', want '%s'", string(after), string(before))
+			}
+			// Normalizing is idempotent.
+			again, _ := json.Marshal(Normalize(Normalize(p)))
+			if string(again) != tc.want {
+				t.Errorf("got '%s', want '%s'", string(again), tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeIntrinsics(t *testing.T) {
+	in := `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*",
+		"Resource": ["arn:aws:s3:::z", {"Fn::GetAtt": ["Bucket", "Arn"]}, "arn:aws:s3:::a"]}}`
+	p, err := Unmarshal([]byte(in), AllowIntrinsics())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := json.Marshal(Normalize(p).Statements.Values()[0].Resource)
+	want := `["arn:aws:s3:::a","arn:aws:s3:::z",{"Fn::GetAtt":["Bucket","Arn"]}]`
+	if string(got) != want {
+		t.Errorf("got '%s', want '%s'", string(got), want)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	a := `{"Version": "2012-10-17", "Statement": [
+		{"Effect": "Allow", "Principal": {"AWS": "111122223333"}, "Action": ["S3:getObject", "s3:PutObject"], "Resource": "*"},
+		{"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`
+	b := `{"Version": "2012-10-17", "Statement": [
+		{"Effect": "Deny", "Action": ["s3:DeleteObject"], "Resource": ["*"]},
+		{"Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::111122223333:root"]}, "Action": ["s3:PutObject", "s3:GetObject"], "Resource": "*"}]}`
+	c := `{"Version": "2012-10-17", "Statement": {"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}}`
+	pa, _ := Unmarshal([]byte(a))
+	pb, _ := Unmarshal([]byte(b))
+	pc, _ := Unmarshal([]byte(c))
+	if !Equivalent(pa, pb) {
+		t.Errorf("got not equivalent, want equivalent")
+	}
+	if Equivalent(pa, pc) {
+		t.Errorf("got equivalent, want not equivalent")
+	}
+	if Equivalent(pa, nil) || !Equivalent(nil, nil) {
+		t.Errorf("got wrong result for nil policies")
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
 )
 
 const (
@@ -27,25 +30,44 @@ type Policy struct {
 	Id         string            `json:"Id,omitempty"`
 	Statements *StatementOrSlice `json:"Statement"`
 	Version    string            `json:"Version"`
+
+	// Extra holds fields of the policy that are not part of the policy
+	// grammar. It is only set by Unmarshal with UnknownFieldsCapture, and is
+	// written after the known fields when marshaling.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// keys is the key order of a policy decoded with PreserveKeyOrder.
+	keys []string
+	// duplicates are the repeated keys found by Unmarshal, reported by
+	// Validate.
+	duplicates ValidationErrors
 }
 
 // Statement is a single statement in a policy document.
 type Statement struct {
-	Action       *StringOrSlice                        `json:"Action,omitempty"`
-	Condition    map[string]map[string]*ConditionValue `json:"Condition,omitempty"`
-	Effect       string                                `json:"Effect"`
-	NotAction    *StringOrSlice                        `json:"NotAction,omitempty"`
-	NotResource  *StringOrSlice                        `json:"NotResource,omitempty"`
-	Principal    *Principal                            `json:"Principal,omitempty"`
-	NotPrincipal *Principal                            `json:"NotPrincipal,omitempty"`
-	Resource     *StringOrSlice                        `json:"Resource,omitempty"`
-	Sid          string                                `json:"Sid,omitempty"`
+	Action       *StringOrSlice `json:"Action,omitempty"`
+	Condition    Condition      `json:"Condition,omitempty"`
+	Effect       string         `json:"Effect"`
+	NotAction    *StringOrSlice `json:"NotAction,omitempty"`
+	NotResource  *StringOrSlice `json:"NotResource,omitempty"`
+	Principal    *Principal     `json:"Principal,omitempty"`
+	NotPrincipal *Principal     `json:"NotPrincipal,omitempty"`
+	Resource     *StringOrSlice `json:"Resource,omitempty"`
+	Sid          string         `json:"Sid,omitempty"`
+
+	// Extra holds fields of the statement that are not part of the policy
+	// grammar. It is only set by Unmarshal with UnknownFieldsCapture, and is
+	// written after the known fields when marshaling.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // StatementOrSlice represents Statements that can be marshaled to a single Statement or a slice of Statements.
 type StatementOrSlice struct {
 	values   []Statement
 	singular bool
+	// layouts holds the key order of each statement decoded with
+	// PreserveKeyOrder, by index. It may be shorter than values.
+	layouts []*statementLayout
 }
 
 // NewSingularStatementOrSlice creates a new StatementOrSlice with a single Statement.
@@ -80,7 +102,8 @@ func (s *StatementOrSlice) UnmarshalJSON(data []byte) error {
 	}
 	_, ok := tmp.([]interface{})
 	if ok {
-		// TODO: can we avoid strict decoding and defer to the outer
+		// Unknown fields are always rejected here. Use Unmarshal with
+		// UnknownFields to choose how they are handled.
 		values := []Statement{}
 		decoder := json.NewDecoder(bytes.NewReader(data))
 		decoder.DisallowUnknownFields()
@@ -114,6 +137,9 @@ func (s *StatementOrSlice) MarshalJSON() ([]byte, error) {
 	enc := json.NewEncoder(&buf)
 	enc.SetEscapeHTML(false)
 
+	if s.layouts != nil {
+		return s.marshalWithLayouts()
+	}
 	if s.singular && len(s.values) == 1 {
 		err := enc.Encode(s.values[0])
 		return []byte(strings.TrimSpace(buf.String())), err
@@ -122,6 +148,29 @@ func (s *StatementOrSlice) MarshalJSON() ([]byte, error) {
 	return []byte(strings.TrimSpace(buf.String())), err
 }
 
+// marshalWithLayouts encodes the statements, using the decoded key order of
+// each statement that has one.
+func (s *StatementOrSlice) marshalWithLayouts() ([]byte, error) {
+	values := make([]json.RawMessage, len(s.values))
+	for i, statement := range s.values {
+		var b []byte
+		var err error
+		if i < len(s.layouts) && s.layouts[i] != nil {
+			b, err = marshalStatement(statement, s.layouts[i])
+		} else {
+			b, err = marshalValue(statement)
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[i] = b
+	}
+	if s.singular && len(values) == 1 {
+		return values[0], nil
+	}
+	return marshalValue(values)
+}
+
 // Values returns the statement values of the StatementOrSlice.
 func (s *StatementOrSlice) Values() []Statement {
 	return s.values
@@ -131,3 +180,13 @@ func (s *StatementOrSlice) Values() []Statement {
 func (s *StatementOrSlice) Singular() bool {
 	return s.singular
 }
+
+// Pointer returns the JSON pointer of the statement at index i, such as
+// "/Statement/0". A singular statement is not wrapped in an array, so its
+// pointer is "/Statement".
+func (s *StatementOrSlice) Pointer(i int) string {
+	if s.Singular() && len(s.Values()) == 1 {
+		return "/Statement"
+	}
+	return jsonpointer.Append("/Statement", strconv.Itoa(i))
+}
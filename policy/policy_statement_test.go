@@ -274,3 +274,23 @@ func TestStatementOrSliceMarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestStatementOrSlicePointer(t *testing.T) {
+	cases := []struct {
+		name string
+		in   *StatementOrSlice
+		i    int
+		want string
+	}{
+		{name: "Singular", in: NewSingularStatementOrSlice(Statement{Effect: EffectAllow}), i: 0, want: "/Statement"},
+		{name: "SliceOfOne", in: NewStatementOrSlice(Statement{Effect: EffectAllow}), i: 0, want: "/Statement/0"},
+		{name: "Slice", in: NewStatementOrSlice(Statement{Effect: EffectAllow}, Statement{Effect: EffectDeny}), i: 1, want: "/Statement/1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.in.Pointer(tc.i); got != tc.want {
+				t.Errorf("got '%s', want '%s'", got, tc.want)
+			}
+		})
+	}
+}
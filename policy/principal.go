@@ -16,6 +16,11 @@ const (
 type Principal struct {
 	principal *principal
 	str       string
+
+	// Extra holds fields of the principal object that are not part of the
+	// policy grammar. It is only set by Unmarshal with UnknownFieldsCapture,
+	// and is written after the known fields when marshaling.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // AddService adds one or more services to the Principal.
@@ -121,6 +126,13 @@ func (p *Principal) MarshalJSON() ([]byte, error) {
 	if p.str != "" {
 		return json.Marshal(p.str)
 	}
+	if len(p.Extra) > 0 {
+		inner := p.principal
+		if inner == nil {
+			inner = &principal{}
+		}
+		return inner.marshalJSON(p.Extra)
+	}
 	return json.Marshal(p.principal)
 }
 
@@ -130,6 +142,9 @@ func (p *Principal) Kinds() []string {
 		return []string{PrincipalKindAll}
 	}
 	resp := []string{}
+	if p.principal == nil {
+		return resp
+	}
 	if p.principal.AWS != nil {
 		resp = append(resp, PrincipalKindAWS)
 	}
@@ -183,6 +198,9 @@ type principal struct {
 	CanonicalUser *StringOrSlice `json:"CanonicalUser,omitempty"`
 	Federated     *StringOrSlice `json:"Federated,omitempty"`
 	Service       *StringOrSlice `json:"Service,omitempty"`
+
+	// keys is the key order of a principal decoded with PreserveKeyOrder.
+	keys []string
 }
 
 func (p *principal) AddAWS(aws ...string) {
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
 )
 
 const (
@@ -28,6 +32,10 @@ func NewStringOrSlice(singular bool, values ...string) *StringOrSlice {
 type StringOrSlice struct {
 	values   []string
 	singular bool
+	// intrinsics holds the CloudFormation intrinsic function of each value
+	// decoded with AllowIntrinsics, by index. The value holds the JSON text of
+	// the intrinsic.
+	intrinsics []*Intrinsic
 }
 
 func (s *StringOrSlice) Add(value ...string) {
@@ -70,6 +78,21 @@ func (s *StringOrSlice) MarshalJSON() ([]byte, error) {
 	enc := json.NewEncoder(&buf)
 	enc.SetEscapeHTML(false)
 
+	if s.HasIntrinsics() {
+		values := make([]interface{}, len(s.values))
+		for i, value := range s.values {
+			values[i] = value
+			if intrinsic := s.Intrinsic(i); intrinsic != nil {
+				values[i] = intrinsic
+			}
+		}
+		if s.singular && len(values) == 1 {
+			err := enc.Encode(values[0])
+			return []byte(strings.TrimSpace(buf.String())), err
+		}
+		err := enc.Encode(values)
+		return []byte(strings.TrimSpace(buf.String())), err
+	}
 	if s.singular && len(s.values) == 1 {
 		err := enc.Encode(s.values[0])
 		return []byte(strings.TrimSpace(buf.String())), err
@@ -88,3 +111,49 @@ func (s *StringOrSlice) Values() []string {
 func (s *StringOrSlice) IsSingular() bool {
 	return s.singular && len(s.values) <= 1
 }
+
+// Intrinsic returns the CloudFormation intrinsic function of the value at
+// index i, or nil if the value is a plain string.
+func (s *StringOrSlice) Intrinsic(i int) *Intrinsic {
+	if i < 0 || i >= len(s.intrinsics) {
+		return nil
+	}
+	return s.intrinsics[i]
+}
+
+// HasIntrinsics returns true if any value is a CloudFormation intrinsic
+// function.
+func (s *StringOrSlice) HasIntrinsics() bool {
+	if s == nil {
+		return false
+	}
+	for _, intrinsic := range s.intrinsics {
+		if intrinsic != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveIntrinsics replaces intrinsic values with their resolved strings.
+func (s *StringOrSlice) resolveIntrinsics(path string, params map[string]string) error {
+	if !s.HasIntrinsics() {
+		return nil
+	}
+	for i, intrinsic := range s.intrinsics {
+		if intrinsic == nil {
+			continue
+		}
+		value, err := intrinsic.Resolve(params)
+		if err != nil {
+			if !s.IsSingular() {
+				path = jsonpointer.Append(path, strconv.Itoa(i))
+			}
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		s.values[i] = value
+		s.intrinsics[i] = nil
+	}
+	s.intrinsics = nil
+	return nil
+}
@@ -0,0 +1,212 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/aws-iam-policy/internal/jsonpointer"
+)
+
+// Validation error codes.
+const (
+	ValidationMissingVersion           = "MissingVersion"
+	ValidationUnknownVersion           = "UnknownVersion"
+	ValidationMissingStatement         = "MissingStatement"
+	ValidationDuplicateSid             = "DuplicateSid"
+	ValidationDuplicateKey             = "DuplicateKey"
+	ValidationInvalidEffect            = "InvalidEffect"
+	ValidationMissingAction            = "MissingAction"
+	ValidationActionAndNotAction       = "ActionAndNotAction"
+	ValidationMissingResource          = "MissingResource"
+	ValidationResourceAndNotResource   = "ResourceAndNotResource"
+	ValidationPrincipalAndNotPrincipal = "PrincipalAndNotPrincipal"
+	ValidationInvalidPrincipal         = "InvalidPrincipal"
+	ValidationEmptyValue               = "EmptyValue"
+	ValidationInvalidConditionOperator = "InvalidConditionOperator"
+	ValidationMixedConditionValue      = "MixedConditionValue"
+)
+
+// ValidationError is a structural problem found in a policy document.
+type ValidationError struct {
+	// Path is a JSON pointer (RFC 6901) to the offending element, such as
+	// "/Statement/1/Action".
+	Path string
+	// Code is one of the Validation* constants.
+	Code    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors is a list of validation errors.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationErrors) add(path, code, format string, args ...interface{}) {
+	*e = append(*e, &ValidationError{Path: path, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the policy for structural errors that IAM would reject. For
+// a policy decoded with Unmarshal, keys repeated in the policy, its statements,
+// principals or conditions are also reported. It returns nil if no errors are
+// found.
+func (p *Policy) Validate() ValidationErrors {
+	var errs ValidationErrors
+	for _, err := range p.duplicates {
+		dup := *err
+		errs = append(errs, &dup)
+	}
+	switch p.Version {
+	case "":
+		errs.add("/Version", ValidationMissingVersion, "Version is required")
+	case Version2012_10_17, Version2008_10_17:
+	default:
+		errs.add("/Version", ValidationUnknownVersion, "unknown Version %q", p.Version)
+	}
+
+	if p.Statements == nil || len(p.Statements.Values()) == 0 {
+		errs.add("/Statement", ValidationMissingStatement, "Statement is required")
+		return errs
+	}
+	sids := map[string]int{}
+	for i, statement := range p.Statements.Values() {
+		path := p.Statements.Pointer(i)
+		if statement.Sid != "" {
+			if first, ok := sids[statement.Sid]; ok {
+				errs.add(jsonpointer.Append(path, "Sid"), ValidationDuplicateSid, "Sid %q is already used by statement %d", statement.Sid, first)
+			} else {
+				sids[statement.Sid] = i
+			}
+		}
+		for _, err := range statement.Validate() {
+			err.Path = path + err.Path
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Validate checks the statement for structural errors that IAM would reject.
+// Paths in the returned errors are relative to the statement. A statement with
+// neither a Principal nor a NotPrincipal is treated as part of an
+// identity-based policy, and must have a Resource or NotResource. It returns
+// nil if no errors are found.
+func (s *Statement) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if s.Effect != EffectAllow && s.Effect != EffectDeny {
+		errs.add("/Effect", ValidationInvalidEffect, "Effect must be %q or %q, got %q", EffectAllow, EffectDeny, s.Effect)
+	}
+
+	switch {
+	case s.Action != nil && s.NotAction != nil:
+		errs.add("/NotAction", ValidationActionAndNotAction, "Action and NotAction cannot both be set")
+	case s.Action == nil && s.NotAction == nil:
+		errs.add("/Action", ValidationMissingAction, "one of Action or NotAction is required")
+	}
+	validateStringOrSlice(&errs, "/Action", s.Action)
+	validateStringOrSlice(&errs, "/NotAction", s.NotAction)
+
+	identityBased := s.Principal == nil && s.NotPrincipal == nil
+	switch {
+	case s.Resource != nil && s.NotResource != nil:
+		errs.add("/NotResource", ValidationResourceAndNotResource, "Resource and NotResource cannot both be set")
+	case s.Resource == nil && s.NotResource == nil && identityBased:
+		errs.add("/Resource", ValidationMissingResource, "one of Resource or NotResource is required in identity-based policies")
+	}
+	validateStringOrSlice(&errs, "/Resource", s.Resource)
+	validateStringOrSlice(&errs, "/NotResource", s.NotResource)
+
+	if s.Principal != nil && s.NotPrincipal != nil {
+		errs.add("/NotPrincipal", ValidationPrincipalAndNotPrincipal, "Principal and NotPrincipal cannot both be set")
+	}
+	validatePrincipal(&errs, "/Principal", s.Principal)
+	validatePrincipal(&errs, "/NotPrincipal", s.NotPrincipal)
+
+	validateCondition(&errs, "/Condition", s.Condition)
+	return errs
+}
+
+func validateStringOrSlice(errs *ValidationErrors, path string, s *StringOrSlice) {
+	if s == nil {
+		return
+	}
+	if len(s.Values()) == 0 {
+		errs.add(path, ValidationEmptyValue, "value cannot be empty")
+		return
+	}
+	for i, value := range s.Values() {
+		if value != "" {
+			continue
+		}
+		if s.IsSingular() {
+			errs.add(path, ValidationEmptyValue, "value cannot be an empty string")
+		} else {
+			errs.add(jsonpointer.Append(path, strconv.Itoa(i)), ValidationEmptyValue, "value cannot be an empty string")
+		}
+	}
+}
+
+func validatePrincipal(errs *ValidationErrors, path string, p *Principal) {
+	if p == nil {
+		return
+	}
+	if p.str != "" && p.str != PrincipalAll {
+		errs.add(path, ValidationInvalidPrincipal, "principal string must be %q, got %q", PrincipalAll, p.str)
+		return
+	}
+	kinds := p.Kinds()
+	if len(kinds) == 0 {
+		errs.add(path, ValidationEmptyValue, "principal cannot be empty")
+		return
+	}
+	for _, kind := range kinds {
+		switch kind {
+		case PrincipalKindAWS:
+			validateStringOrSlice(errs, jsonpointer.Append(path, kind), p.AWS())
+		case PrincipalKindCanonical:
+			validateStringOrSlice(errs, jsonpointer.Append(path, kind), p.CanonicalUser())
+		case PrincipalKindFederated:
+			validateStringOrSlice(errs, jsonpointer.Append(path, kind), p.Federated())
+		case PrincipalKindService:
+			validateStringOrSlice(errs, jsonpointer.Append(path, kind), p.Service())
+		}
+	}
+}
+
+func validateCondition(errs *ValidationErrors, path string, c Condition) {
+	for _, operator := range c.Operators() {
+		opPath := jsonpointer.Append(path, operator)
+		op, err := ParseConditionOperator(operator)
+		if err != nil {
+			errs.add(opPath, ValidationInvalidConditionOperator, "%v", err)
+			continue
+		}
+		if !op.IsKnown() {
+			errs.add(opPath, ValidationInvalidConditionOperator, "%s %q", ErrorUnknownConditionOperator, operator)
+		}
+		block := c.Block(operator)
+		if len(block) == 0 {
+			errs.add(opPath, ValidationEmptyValue, "condition operator has no keys")
+		}
+		for _, key := range block.Keys() {
+			switch {
+			case block[key] == nil || block[key].isEmpty():
+				errs.add(jsonpointer.Append(opPath, key), ValidationEmptyValue, "condition value cannot be empty")
+			case block[key].IsMixed():
+				// IAM compares every value of a key with the same operator, so
+				// an array mixing types cannot be meant literally.
+				errs.add(jsonpointer.Append(opPath, key), ValidationMixedConditionValue, "condition value array mixes strings, bools and numbers")
+			}
+		}
+	}
+}
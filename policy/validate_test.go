@@ -0,0 +1,262 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		in    string
+		want  []string
+		codes []string
+	}{
+		{
+			name: "Valid",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": {
+					"Effect": "Allow",
+					"Action": "s3:GetObject",
+					"Resource": "arn:aws:s3:::examplebucket/*"
+				}
+			}`,
+		},
+		{
+			name: "ValidTrustPolicy",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": [{
+					"Effect": "Allow",
+					"Principal": {"Service": "ec2.amazonaws.com"},
+					"Action": "sts:AssumeRole"
+				}]
+			}`,
+		},
+		{
+			name: "MissingVersionAndStatement",
+			in:   `{"Statement": []}`,
+			want: []string{
+				"/Version: Version is required",
+				"/Statement: Statement is required",
+			},
+			codes: []string{ValidationMissingVersion, ValidationMissingStatement},
+		},
+		{
+			name: "UnknownVersion",
+			in: `{
+				"Version": "2012-10-18",
+				"Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*"}
+			}`,
+			want:  []string{`/Version: unknown Version "2012-10-18"`},
+			codes: []string{ValidationUnknownVersion},
+		},
+		{
+			name: "SingularStatementPaths",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": {"Effect": "allow", "Action": "s3:*", "NotAction": "iam:*"}
+			}`,
+			want: []string{
+				`/Statement/Effect: Effect must be "Allow" or "Deny", got "allow"`,
+				"/Statement/NotAction: Action and NotAction cannot both be set",
+				"/Statement/Resource: one of Resource or NotResource is required in identity-based policies",
+			},
+			codes: []string{ValidationInvalidEffect, ValidationActionAndNotAction, ValidationMissingResource},
+		},
+		{
+			name: "StatementErrors",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": [
+					{"Sid": "One", "Effect": "Allow", "Action": "s3:*", "Resource": "*"},
+					{
+						"Sid": "One",
+						"Effect": "Deny",
+						"Principal": {"AWS": ["111122223333", ""]},
+						"NotPrincipal": {"Service": "ec2.amazonaws.com"},
+						"Resource": "*",
+						"NotResource": [],
+						"Condition": {
+							"StringEqual": {"aws:SourceAccount": "111122223333"},
+							"ForEach:StringLike": {"aws:TagKeys": "a"},
+							"StringLike": {"aws:PrincipalTag/team": []}
+						}
+					}
+				]
+			}`,
+			want: []string{
+				`/Statement/1/Sid: Sid "One" is already used by statement 0`,
+				"/Statement/1/Action: one of Action or NotAction is required",
+				"/Statement/1/NotResource: Resource and NotResource cannot both be set",
+				"/Statement/1/NotResource: value cannot be empty",
+				"/Statement/1/NotPrincipal: Principal and NotPrincipal cannot both be set",
+				"/Statement/1/Principal/AWS/1: value cannot be an empty string",
+				`/Statement/1/Condition/ForEach:StringLike: invalid condition operator "ForEach:StringLike": unknown qualifier "ForEach"`,
+				`/Statement/1/Condition/StringEqual: unknown condition operator "StringEqual"`,
+				"/Statement/1/Condition/StringLike/aws:PrincipalTag~1team: condition value cannot be empty",
+			},
+			codes: []string{
+				ValidationDuplicateSid,
+				ValidationMissingAction,
+				ValidationResourceAndNotResource,
+				ValidationEmptyValue,
+				ValidationPrincipalAndNotPrincipal,
+				ValidationEmptyValue,
+				ValidationInvalidConditionOperator,
+				ValidationInvalidConditionOperator,
+				ValidationEmptyValue,
+			},
+		},
+		{
+			name: "InvalidPrincipalString",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": {"Effect": "Allow", "Principal": "111122223333", "Action": "s3:*", "Resource": ""}
+			}`,
+			want: []string{
+				"/Statement/Resource: value cannot be an empty string",
+				`/Statement/Principal: principal string must be "*", got "111122223333"`,
+			},
+			codes: []string{ValidationEmptyValue, ValidationInvalidPrincipal},
+		},
+		{
+			name: "MixedConditionValue",
+			in: `{
+				"Version": "2012-10-17",
+				"Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": "*",
+					"Condition": {"StringEquals": {"aws:PrincipalTag/team": ["a", true], "aws:PrincipalAccount": ["1", "2"]}}}
+			}`,
+			want: []string{
+				"/Statement/Condition/StringEquals/aws:PrincipalTag~1team: condition value array mixes strings, bools and numbers",
+			},
+			codes: []string{ValidationMixedConditionValue},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p Policy
+			if err := json.Unmarshal([]byte(tc.in), &p); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			errs := p.Validate()
+			if len(errs) != len(tc.want) {
+				t.Fatalf("got %d errors '%v', want %d", len(errs), errs, len(tc.want))
+			}
+			for i, err := range errs {
+				if err.Error() != tc.want[i] {
+					t.Errorf("got '%s', want '%s'", err.Error(), tc.want[i])
+				}
+				if err.Code != tc.codes[i] {
+					t.Errorf("got '%s', want '%s'", err.Code, tc.codes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStatementValidate(t *testing.T) {
+	s := Statement{
+		Effect:   EffectAllow,
+		Action:   NewStringOrSlice(false, "s3:GetObject", ""),
+		Resource: NewStringOrSlice(true, "*"),
+	}
+	errs := s.Validate()
+	want := "/Action/1: value cannot be an empty string"
+	if len(errs) != 1 || errs.Error() != want {
+		t.Errorf("got '%v', want '%s'", errs, want)
+	}
+}
+
+func TestValidateFixtures(t *testing.T) {
+	b, err := os.ReadFile("./test_fixtures/valid_bucket_policies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := []*Policy{}
+	if err := json.Unmarshal(b, &policies); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range policies {
+		t.Run(fmt.Sprintf("Validate S3 policy %d", i), func(t *testing.T) {
+			for _, err := range p.Validate() {
+				// Some of the S3 documentation examples reuse a Sid across
+				// statements, which S3 accepts but IAM does not.
+				if err.Code == ValidationDuplicateSid {
+					continue
+				}
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateDuplicateKeys(t *testing.T) {
+	in := `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Principal": {"AWS": "*", "aws": "arn:aws:iam::111122223333:root"},
+      "Action": "s3:GetObject",
+      "Resource": "*",
+      "Condition": {
+        "StringEquals": {"aws:SourceVpc": "vpc-1", "aws:SourceVpc": "vpc-2"},
+        "Bool": {"aws:SecureTransport": "true"},
+        "StringEquals": {"aws:SourceAccount": "111122223333"}
+      },
+      "Condition": {"Bool": {"aws:SecureTransport": "true"}}
+    }
+  ],
+  "Version": "2012-10-17"
+}`
+	p, err := Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`/Statement/0/Principal/aws: duplicate key "aws" at line 6, column 33`,
+		`/Statement/0/Condition/StringEquals/aws:SourceVpc: duplicate key "aws:SourceVpc" at line 10, column 52`,
+		`/Statement/0/Condition/StringEquals: duplicate key "StringEquals" at line 12, column 9`,
+		`/Statement/0/Condition: duplicate key "Condition" at line 14, column 7`,
+		`/Version: duplicate key "Version" at line 17, column 3`,
+	}
+	errs := p.Validate()
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors '%v', want %d", len(errs), errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("got '%s', want '%s'", err.Error(), want[i])
+		}
+		if err.Code != ValidationDuplicateKey {
+			t.Errorf("got '%s', want '%s'", err.Code, ValidationDuplicateKey)
+		}
+	}
+
+	// Condition keys are matched case-insensitively.
+	foldIn := `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "*", "Resource": "*",
+  "Condition": {"IpAddress": {"aws:SourceIp": "10.0.0.0/8", "aws:sourceip": "192.168.0.0/16"}}}}`
+	folded, err := Unmarshal([]byte(foldIn))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantFolded := `/Statement/Condition/IpAddress/aws:sourceip: duplicate key "aws:sourceip" at line 2, column 61`
+	if errs := folded.Validate(); len(errs) != 1 || errs[0].Error() != wantFolded {
+		t.Errorf("got '%v', want '%s'", errs, wantFolded)
+	}
+
+	// Repeated keys decode like encoding/json.
+	var std Policy
+	if err := json.Unmarshal([]byte(in), &std); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantb, _ := json.Marshal(std)
+	gotb, _ := json.Marshal(p)
+	if string(gotb) != string(wantb) {
+		t.Errorf("got '%s', want '%s'", string(gotb), string(wantb))
+	}
+}
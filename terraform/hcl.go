@@ -0,0 +1,565 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/micahhausler/aws-iam-policy/policy"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const (
+	ErrorHCLSyntax          = "invalid HCL"
+	ErrorNonLiteral         = "only literal values are supported"
+	ErrorUnsupportedBlock   = "unsupported block"
+	ErrorUnsupportedAttr    = "unsupported attribute"
+	ErrorUnsupportedValue   = "value cannot be written as HCL"
+	ErrorInvalidPrincipal   = "principals must have a type and identifiers"
+	ErrorInvalidCondition   = "condition must have a test, variable and values"
+	ErrorExpectedSingle     = "expected a single string"
+	ErrorExpectedList       = "expected a list of strings"
+	ErrorNoPolicyDocument   = "no aws_iam_policy_document data source found"
+	policyDocumentType      = "aws_iam_policy_document"
+	defaultPolicyDocVersion = policy.VersionLatest
+)
+
+// PolicyDocument is an aws_iam_policy_document data source.
+type PolicyDocument struct {
+	// Name is the name of the data source, as in
+	// data "aws_iam_policy_document" "<name>".
+	Name   string
+	Policy *policy.Policy
+}
+
+// FormatHCL renders a policy as an aws_iam_policy_document data source, in
+// the layout of terraform fmt. Strings are escaped so that policy variables
+// such as ${aws:username} are kept literally. Condition values that are bools
+// or numbers are written as strings, and an error is returned for policies
+// holding values that cannot be written as HCL, such as intrinsic functions.
+func FormatHCL(name string, p *policy.Policy) ([]byte, error) {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body().AppendNewBlock("data", []string{policyDocumentType, name}).Body()
+	if p.Id != "" {
+		body.SetAttributeValue("policy_id", cty.StringVal(p.Id))
+	}
+	if p.Version != "" {
+		body.SetAttributeValue("version", cty.StringVal(p.Version))
+	}
+	if p.Statements != nil {
+		for _, s := range p.Statements.Values() {
+			if err := writeStatement(body, s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return hclwrite.Format(f.Bytes()), nil
+}
+
+// appendBlock appends a nested block to body, separated from anything before
+// it by a blank line.
+func appendBlock(body *hclwrite.Body, typ string) *hclwrite.Body {
+	if len(body.Attributes()) > 0 || len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	return body.AppendNewBlock(typ, nil).Body()
+}
+
+// setList sets an attribute to a list of strings. Lists of more than one
+// string are written with one string per line.
+func setList(body *hclwrite.Body, name string, values []string) {
+	if len(values) <= 1 {
+		items := make([]cty.Value, len(values))
+		for i, v := range values {
+			items[i] = cty.StringVal(v)
+		}
+		body.SetAttributeValue(name, cty.TupleVal(items))
+		return
+	}
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	}
+	for _, v := range values {
+		tokens = append(tokens, hclwrite.TokensForValue(cty.StringVal(v))...)
+		tokens = append(tokens,
+			&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		)
+	}
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+	body.SetAttributeRaw(name, tokens)
+}
+
+func writeStatement(parent *hclwrite.Body, s policy.Statement) error {
+	if len(s.Extra) > 0 {
+		return fmt.Errorf("%s: unknown statement fields", ErrorUnsupportedValue)
+	}
+	elements := []struct {
+		name  string
+		value *policy.StringOrSlice
+	}{
+		{"actions", s.Action},
+		{"not_actions", s.NotAction},
+		{"resources", s.Resource},
+		{"not_resources", s.NotResource},
+	}
+	for _, f := range elements {
+		if f.value != nil && f.value.HasIntrinsics() {
+			return fmt.Errorf("%s: %s contains an intrinsic function", ErrorUnsupportedValue, f.name)
+		}
+	}
+	body := appendBlock(parent, "statement")
+	if s.Sid != "" {
+		body.SetAttributeValue("sid", cty.StringVal(s.Sid))
+	}
+	body.SetAttributeValue("effect", cty.StringVal(s.Effect))
+	for _, f := range elements {
+		if f.value != nil {
+			setList(body, f.name, f.value.Values())
+		}
+	}
+
+	for _, f := range []struct {
+		name  string
+		value *policy.Principal
+	}{
+		{"principals", s.Principal},
+		{"not_principals", s.NotPrincipal},
+	} {
+		if err := writePrincipals(body, f.name, f.value); err != nil {
+			return err
+		}
+	}
+
+	for _, op := range s.Condition.Operators() {
+		block := s.Condition.Block(op)
+		for _, key := range block.Keys() {
+			values := []string{}
+			if block[key] != nil {
+				for _, e := range block[key].Elements() {
+					switch e.Kind {
+					case policy.ConditionElementString:
+						values = append(values, e.String)
+					case policy.ConditionElementBool:
+						values = append(values, fmt.Sprint(e.Bool))
+					case policy.ConditionElementNumber:
+						values = append(values, e.Number.String())
+					default:
+						return fmt.Errorf("%s: condition %s %s contains an intrinsic function", ErrorUnsupportedValue, op, key)
+					}
+				}
+			}
+			cb := appendBlock(body, "condition")
+			cb.SetAttributeValue("test", cty.StringVal(op))
+			cb.SetAttributeValue("variable", cty.StringVal(key))
+			setList(cb, "values", values)
+		}
+	}
+	return nil
+}
+
+func writePrincipals(body *hclwrite.Body, name string, p *policy.Principal) error {
+	if p == nil {
+		return nil
+	}
+	if len(p.Extra) > 0 {
+		return fmt.Errorf("%s: unknown %s fields", ErrorUnsupportedValue, name)
+	}
+	for _, kind := range p.Kinds() {
+		var value *policy.StringOrSlice
+		typ := kind
+		switch kind {
+		case policy.PrincipalKindAll:
+			typ = policy.PrincipalAll
+			value = policy.NewStringOrSlice(false, policy.PrincipalAll)
+		case policy.PrincipalKindAWS:
+			value = p.AWS()
+		case policy.PrincipalKindCanonical:
+			value = p.CanonicalUser()
+		case policy.PrincipalKindFederated:
+			value = p.Federated()
+		case policy.PrincipalKindService:
+			value = p.Service()
+		}
+		if value.HasIntrinsics() {
+			return fmt.Errorf("%s: %s contains an intrinsic function", ErrorUnsupportedValue, name)
+		}
+		pb := appendBlock(body, name)
+		pb.SetAttributeValue("type", cty.StringVal(typ))
+		setList(pb, "identifiers", value.Values())
+	}
+	return nil
+}
+
+// ParseHCL reads the aws_iam_policy_document data sources in an HCL file.
+//
+// The file may only contain aws_iam_policy_document data sources, and only
+// the policy_id and version attributes and the statement blocks of the data
+// source are supported. Other attributes, such as source_policy_documents,
+// and other blocks, such as dynamic blocks, are rejected with an error.
+//
+// Values must be literals: strings, including heredocs and strings with
+// escaped template sequences such as $${aws:username}, numbers, bools, and
+// lists of them. References, function calls and template interpolations are
+// rejected with an error, as they cannot be evaluated without Terraform.
+// As in Terraform, policy variables written as &{aws:username} in list
+// values, such as resources or condition values, are read as ${aws:username}.
+func ParseHCL(data []byte) ([]*PolicyDocument, error) {
+	file, diags := hclsyntax.ParseConfig(data, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diagnosticError(ErrorHCLSyntax, diags)
+	}
+	body := file.Body.(*hclsyntax.Body)
+	if attrs := sortedAttributes(body); len(attrs) > 0 {
+		return nil, unsupportedAttribute(attrs[0])
+	}
+	var docs []*PolicyDocument
+	for _, b := range body.Blocks {
+		if b.Type != "data" || len(b.Labels) != 2 || b.Labels[0] != policyDocumentType {
+			return nil, unsupportedBlock(b)
+		}
+		pol, err := parsePolicy(data, b)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, &PolicyDocument{Name: b.Labels[1], Policy: pol})
+	}
+	if len(docs) == 0 {
+		return nil, errors.New(ErrorNoPolicyDocument)
+	}
+	return docs, nil
+}
+
+// diagnosticError returns an error for the first error in diags.
+func diagnosticError(kind string, diags hcl.Diagnostics) error {
+	for _, d := range diags {
+		if d.Severity != hcl.DiagError {
+			continue
+		}
+		line := 0
+		if d.Subject != nil {
+			line = d.Subject.Start.Line
+		}
+		summary := strings.TrimSuffix(d.Summary, ".")
+		if summary != "" {
+			summary = strings.ToLower(summary[:1]) + summary[1:]
+		}
+		return fmt.Errorf("line %d: %s: %s", line, kind, summary)
+	}
+	return errors.New(kind)
+}
+
+func describeBlock(b *hclsyntax.Block) string {
+	parts := []string{b.Type}
+	for _, l := range b.Labels {
+		parts = append(parts, fmt.Sprintf("%q", l))
+	}
+	return strings.Join(parts, " ")
+}
+
+func unsupportedBlock(b *hclsyntax.Block) error {
+	return fmt.Errorf("line %d: %s %s", b.TypeRange.Start.Line, ErrorUnsupportedBlock, describeBlock(b))
+}
+
+func unsupportedAttribute(a *hclsyntax.Attribute) error {
+	return fmt.Errorf("line %d: %s %q", a.NameRange.Start.Line, ErrorUnsupportedAttr, a.Name)
+}
+
+// sortedAttributes returns the attributes of a body in the order they appear.
+func sortedAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	resp := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, a := range body.Attributes {
+		resp = append(resp, a)
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].SrcRange.Start.Byte < resp[j].SrcRange.Start.Byte
+	})
+	return resp
+}
+
+// hclAttr is the literal value of an attribute. Attributes with list set
+// hold a list, others a single value.
+type hclAttr struct {
+	name   string
+	values []string
+	list   bool
+	line   int
+}
+
+// literal evaluates an attribute that must hold a literal value.
+func literal(data []byte, a *hclsyntax.Attribute) (hclAttr, error) {
+	resp := hclAttr{name: a.Name, line: a.NameRange.Start.Line}
+	if vars := a.Expr.Variables(); len(vars) > 0 {
+		r := vars[0].SourceRange()
+		return resp, fmt.Errorf("line %d: %s: found %q", r.Start.Line, ErrorNonLiteral, string(r.SliceBytes(data)))
+	}
+	var call *hclsyntax.FunctionCallExpr
+	hclsyntax.VisitAll(a.Expr, func(n hclsyntax.Node) hcl.Diagnostics {
+		if fn, ok := n.(*hclsyntax.FunctionCallExpr); ok && call == nil {
+			call = fn
+		}
+		return nil
+	})
+	if call != nil {
+		return resp, fmt.Errorf("line %d: %s: found %q", call.NameRange.Start.Line, ErrorNonLiteral, call.Name)
+	}
+	v, diags := a.Expr.Value(nil)
+	if diags.HasErrors() {
+		return resp, diagnosticError(ErrorNonLiteral, diags)
+	}
+	if v.IsNull() {
+		return resp, nil
+	}
+	if ty := v.Type(); ty.IsTupleType() || ty.IsListType() || ty.IsSetType() {
+		resp.list = true
+		resp.values = []string{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, e := it.Element()
+			s, ok := literalString(e)
+			if !ok {
+				return resp, fmt.Errorf("line %d: %s: %s", resp.line, resp.name, ErrorExpectedList)
+			}
+			if e.Type() == cty.String {
+				s = strings.ReplaceAll(s, "&{", "${")
+			}
+			resp.values = append(resp.values, s)
+		}
+		return resp, nil
+	}
+	s, ok := literalString(v)
+	if !ok {
+		return resp, fmt.Errorf("line %d: %s: %s", resp.line, resp.name, ErrorExpectedSingle)
+	}
+	resp.values = []string{s}
+	return resp, nil
+}
+
+// literalString converts a string, number or bool to a string.
+func literalString(v cty.Value) (string, bool) {
+	if v.IsNull() || !v.Type().IsPrimitiveType() {
+		return "", false
+	}
+	s, err := convert.Convert(v, cty.String)
+	if err != nil {
+		return "", false
+	}
+	return s.AsString(), true
+}
+
+// single returns the value of an attribute that must be a single string.
+func (a hclAttr) single() (string, error) {
+	if a.list || len(a.values) != 1 {
+		return "", fmt.Errorf("line %d: %s: %s", a.line, a.name, ErrorExpectedSingle)
+	}
+	return a.values[0], nil
+}
+
+// stringOrSlice returns the value of an attribute that must be a list.
+func (a hclAttr) stringOrSlice() (*policy.StringOrSlice, error) {
+	if !a.list {
+		return nil, fmt.Errorf("line %d: %s: %s", a.line, a.name, ErrorExpectedList)
+	}
+	return policy.NewStringOrSlice(len(a.values) == 1, a.values...), nil
+}
+
+// evalAttributes evaluates the attributes of a block, rejecting attributes not in
+// names.
+func evalAttributes(data []byte, body *hclsyntax.Body, names ...string) (map[string]hclAttr, error) {
+	resp := map[string]hclAttr{}
+	for _, a := range sortedAttributes(body) {
+		known := false
+		for _, name := range names {
+			known = known || a.Name == name
+		}
+		if !known {
+			return nil, unsupportedAttribute(a)
+		}
+		v, err := literal(data, a)
+		if err != nil {
+			return nil, err
+		}
+		resp[a.Name] = v
+	}
+	return resp, nil
+}
+
+func parsePolicy(data []byte, b *hclsyntax.Block) (*policy.Policy, error) {
+	p := &policy.Policy{Version: defaultPolicyDocVersion}
+	attrs, err := evalAttributes(data, b.Body, "version", "policy_id")
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := attrs["version"]; ok {
+		if p.Version, err = a.single(); err != nil {
+			return nil, err
+		}
+	}
+	if a, ok := attrs["policy_id"]; ok {
+		if p.Id, err = a.single(); err != nil {
+			return nil, err
+		}
+	}
+	statements := []policy.Statement{}
+	for _, sb := range b.Body.Blocks {
+		if sb.Type != "statement" || len(sb.Labels) != 0 {
+			return nil, unsupportedBlock(sb)
+		}
+		s, err := parseStatement(data, sb)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, s)
+	}
+	p.Statements = policy.NewStatementOrSlice(statements...)
+	return p, nil
+}
+
+func parseStatement(data []byte, b *hclsyntax.Block) (policy.Statement, error) {
+	s := policy.Statement{Effect: policy.EffectAllow}
+	attrs, err := evalAttributes(data, b.Body, "sid", "effect", "actions", "not_actions", "resources", "not_resources")
+	if err != nil {
+		return s, err
+	}
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{
+		{"sid", &s.Sid},
+		{"effect", &s.Effect},
+	} {
+		if a, ok := attrs[f.name]; ok {
+			if *f.value, err = a.single(); err != nil {
+				return s, err
+			}
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value **policy.StringOrSlice
+	}{
+		{"actions", &s.Action},
+		{"not_actions", &s.NotAction},
+		{"resources", &s.Resource},
+		{"not_resources", &s.NotResource},
+	} {
+		if a, ok := attrs[f.name]; ok {
+			if *f.value, err = a.stringOrSlice(); err != nil {
+				return s, err
+			}
+		}
+	}
+	for _, cb := range b.Body.Blocks {
+		if len(cb.Labels) != 0 {
+			return s, unsupportedBlock(cb)
+		}
+		switch cb.Type {
+		case "principals":
+			s.Principal, err = parsePrincipal(data, cb, s.Principal)
+		case "not_principals":
+			s.NotPrincipal, err = parsePrincipal(data, cb, s.NotPrincipal)
+		case "condition":
+			err = parseCondition(data, cb, &s)
+		default:
+			err = unsupportedBlock(cb)
+		}
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// nestedAttributes evaluates the attributes of a principals or condition
+// block, which may not have nested blocks.
+func nestedAttributes(data []byte, b *hclsyntax.Block, names ...string) (map[string]hclAttr, error) {
+	if len(b.Body.Blocks) > 0 {
+		return nil, unsupportedBlock(b.Body.Blocks[0])
+	}
+	return evalAttributes(data, b.Body, names...)
+}
+
+// parsePrincipal adds the principals in the block to p.
+func parsePrincipal(data []byte, b *hclsyntax.Block, p *policy.Principal) (*policy.Principal, error) {
+	line := b.TypeRange.Start.Line
+	attrs, err := nestedAttributes(data, b, "type", "identifiers")
+	if err != nil {
+		return nil, err
+	}
+	typeAttr, ok1 := attrs["type"]
+	idAttr, ok2 := attrs["identifiers"]
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("line %d: %s", line, ErrorInvalidPrincipal)
+	}
+	typ, err := typeAttr.single()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := idAttr.stringOrSlice()
+	if err != nil {
+		return nil, err
+	}
+	if typ == policy.PrincipalAll {
+		if p != nil || len(ids.Values()) != 1 || ids.Values()[0] != policy.PrincipalAll {
+			return nil, fmt.Errorf("line %d: %s", line, ErrorInvalidPrincipal)
+		}
+		return policy.NewGlobalPrincipal(), nil
+	}
+	if p == nil {
+		p = &policy.Principal{}
+	}
+	add := map[string]func(...string){
+		policy.PrincipalKindAWS:       p.AddAWS,
+		policy.PrincipalKindCanonical: p.AddCanonicalUser,
+		policy.PrincipalKindFederated: p.AddFederated,
+		policy.PrincipalKindService:   p.AddService,
+	}
+	fn, ok := add[typ]
+	if !ok {
+		return nil, fmt.Errorf("line %d: %s: unknown type %q", typeAttr.line, ErrorInvalidPrincipal, typ)
+	}
+	fn(ids.Values()...)
+	return p, nil
+}
+
+func parseCondition(data []byte, b *hclsyntax.Block, s *policy.Statement) error {
+	line := b.TypeRange.Start.Line
+	attrs, err := nestedAttributes(data, b, "test", "variable", "values")
+	if err != nil {
+		return err
+	}
+	testAttr, ok1 := attrs["test"]
+	varAttr, ok2 := attrs["variable"]
+	valuesAttr, ok3 := attrs["values"]
+	if !ok1 || !ok2 || !ok3 {
+		return fmt.Errorf("line %d: %s", line, ErrorInvalidCondition)
+	}
+	test, err := testAttr.single()
+	if err != nil {
+		return err
+	}
+	variable, err := varAttr.single()
+	if err != nil {
+		return err
+	}
+	values, err := valuesAttr.stringOrSlice()
+	if err != nil {
+		return err
+	}
+	if existing, ok := s.Condition.Get(test, variable); ok {
+		if err := existing.AddString(values.Values()...); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		return nil
+	}
+	value := policy.NewConditionValueString(len(values.Values()) == 1, values.Values()...)
+	if err := s.Condition.Add(test, variable, value); err != nil {
+		return fmt.Errorf("line %d: %v", testAttr.line, err)
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package terraform
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+func TestParseHCL(t *testing.T) {
+	data, err := os.ReadFile("testdata/policy.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := ParseHCL(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	want := `{"Id":"BucketPolicy","Statement":[` +
+		`{"Action":"s3:GetObject","Condition":{"NumericLessThanEquals":{"s3:max-keys":"10"}},"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::111122223333:root","arn:aws:iam::444455556666:root"]},"Resource":"arn:aws:s3:::examplebucket/home/${aws:username}/*","Sid":"ReadOwnPrefix"},` +
+		`{"Condition":{"Bool":{"aws:SecureTransport":"false"}},"Effect":"Deny","NotAction":"s3:ListBucket","Principal":"*","Resource":"*","Sid":"DenyHTTP"}],"Version":"2012-10-17"}`
+	if docs[0].Name != "bucket" {
+		t.Errorf("got '%s', want '%s'", docs[0].Name, "bucket")
+	}
+	if got := marshal(t, docs[0].Policy); got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+
+	// Formatting the parsed policy gives back the file.
+	out, err := FormatHCL(docs[0].Name, docs[0].Policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != string(data) {
+		t.Errorf("got '%s', want '%s'", string(out), string(data))
+	}
+}
+
+func TestFormatHCL(t *testing.T) {
+	in := `{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": {"Service": "lambda.amazonaws.com", "Federated": "cognito-identity.amazonaws.com"},
+			"Action": ["sts:AssumeRole", "sts:AssumeRoleWithWebIdentity"],
+			"Condition": {"NumericLessThan": {"aws:MultiFactorAuthAge": 3600}, "Bool": {"aws:MultiFactorAuthPresent": true}}
+		}]
+	}`
+	want := `data "aws_iam_policy_document" "trust" {
+  version = "2012-10-17"
+
+  statement {
+    effect = "Allow"
+    actions = [
+      "sts:AssumeRole",
+      "sts:AssumeRoleWithWebIdentity",
+    ]
+
+    principals {
+      type        = "Federated"
+      identifiers = ["cognito-identity.amazonaws.com"]
+    }
+
+    principals {
+      type        = "Service"
+      identifiers = ["lambda.amazonaws.com"]
+    }
+
+    condition {
+      test     = "Bool"
+      variable = "aws:MultiFactorAuthPresent"
+      values   = ["true"]
+    }
+
+    condition {
+      test     = "NumericLessThan"
+      variable = "aws:MultiFactorAuthAge"
+      values   = ["3600"]
+    }
+  }
+}
+`
+	p, err := policy.Unmarshal([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := FormatHCL("trust", p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got '%s', want '%s'", string(got), want)
+	}
+}
+
+func TestFormatHCLIntrinsic(t *testing.T) {
+	in := `{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:*", "Resource": {"Ref": "Bucket"}}}`
+	p, err := policy.Unmarshal([]byte(in), policy.AllowIntrinsics())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = FormatHCL("policy", p)
+	want := "value cannot be written as HCL: resources contains an intrinsic function"
+	if err == nil || err.Error() != want {
+		t.Errorf("got '%v', want '%s'", err, want)
+	}
+}
+
+func TestParseHCLErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "Empty",
+			in:   "# nothing here\n",
+			want: ErrorNoPolicyDocument,
+		},
+		{
+			name: "Reference",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    actions   = ["s3:*"]
+    resources = [aws_s3_bucket.b.arn]
+  }
+}`,
+			want: `line 4: only literal values are supported: found "aws_s3_bucket.b.arn"`,
+		},
+		{
+			name: "Interpolation",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    resources = ["arn:aws:s3:::${var.bucket}/*"]
+  }
+}`,
+			want: `line 3: only literal values are supported: found "var.bucket"`,
+		},
+		{
+			name: "FunctionCall",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    resources = [format("arn:aws:s3:::%s/*", "bucket")]
+  }
+}`,
+			want: `line 3: only literal values are supported: found "format"`,
+		},
+		{
+			name: "Dynamic",
+			in: `data "aws_iam_policy_document" "p" {
+  dynamic "statement" {
+    for_each = []
+  }
+}`,
+			want: `line 2: unsupported block dynamic "statement"`,
+		},
+		{
+			name: "OtherResource",
+			in:   `resource "aws_iam_policy" "p" {}`,
+			want: `line 1: unsupported block resource "aws_iam_policy" "p"`,
+		},
+		{
+			name: "UnsupportedAttribute",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    actions = ["s3:*"]
+    effects = ["Allow"]
+  }
+}`,
+			want: `line 4: unsupported attribute "effects"`,
+		},
+		{
+			name: "ListForString",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    effect = ["Allow"]
+  }
+}`,
+			want: "line 3: effect: expected a single string",
+		},
+		{
+			name: "IncompleteCondition",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    condition {
+      test   = "Bool"
+      values = ["true"]
+    }
+  }
+}`,
+			want: "line 3: condition must have a test, variable and values",
+		},
+		{
+			name: "UnknownPrincipalType",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    principals {
+      type        = "Account"
+      identifiers = ["111122223333"]
+    }
+  }
+}`,
+			want: `line 4: principals must have a type and identifiers: unknown type "Account"`,
+		},
+		{
+			name: "Unterminated",
+			in:   `data "aws_iam_policy_document" "p" {`,
+			want: "line 1: invalid HCL: unclosed configuration block",
+		},
+		{
+			name: "SourcePolicyDocuments",
+			in: `data "aws_iam_policy_document" "p" {
+  source_policy_documents = []
+}`,
+			want: `line 2: unsupported attribute "source_policy_documents"`,
+		},
+		{
+			name: "Splat",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    resources = aws_s3_bucket.b[*].arn
+  }
+}`,
+			want: `line 3: only literal values are supported: found "aws_s3_bucket.b"`,
+		},
+		{
+			name: "NullEffect",
+			in: `data "aws_iam_policy_document" "p" {
+  statement {
+    effect = null
+  }
+}`,
+			want: "line 3: effect: expected a single string",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseHCL([]byte(tc.in))
+			if err == nil {
+				t.Fatalf("expected error '%s'", tc.want)
+			}
+			if err.Error() != tc.want {
+				t.Errorf("got '%s', want '%s'", err.Error(), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseHCLMerge(t *testing.T) {
+	in := `data "aws_iam_policy_document" "a" {
+  statement {
+    actions   = ["s3:GetObject"]
+    resources = ["*"]
+
+    principals {
+      type        = "AWS"
+      identifiers = ["111122223333"]
+    }
+
+    principals {
+      type        = "AWS"
+      identifiers = ["444455556666"]
+    }
+
+    condition {
+      test     = "StringEquals"
+      variable = "aws:PrincipalTag/team"
+      values   = ["a"]
+    }
+
+    condition {
+      test     = "StringEquals"
+      variable = "aws:PrincipalTag/team"
+      values   = ["b"]
+    }
+  }
+}
+
+/* A second document. */
+data "aws_iam_policy_document" "b" {
+  // Defaults to Allow and the latest version.
+  statement {
+    actions   = ["s3:ListBucket"]
+    resources = ["*"]
+  }
+}
+`
+	docs, err := ParseHCL([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`a {"Statement":[{"Action":"s3:GetObject","Condition":{"StringEquals":{"aws:PrincipalTag/team":["a","b"]}},"Effect":"Allow","Principal":{"AWS":["111122223333","444455556666"]},"Resource":"*"}],"Version":"2012-10-17"}`,
+		`b {"Statement":[{"Action":"s3:ListBucket","Effect":"Allow","Resource":"*"}],"Version":"2012-10-17"}`,
+	}
+	if len(docs) != len(want) {
+		t.Fatalf("got %d documents, want %d", len(docs), len(want))
+	}
+	for i, doc := range docs {
+		got := strings.Join([]string{doc.Name, marshal(t, doc.Policy)}, " ")
+		if got != want[i] {
+			t.Errorf("got '%s', want '%s'", got, want[i])
+		}
+	}
+}
+
+func TestParseHCLLiterals(t *testing.T) {
+	in := `data "aws_iam_policy_document" "p" {
+  statement {
+    sid       = <<-EOT
+      Heredoc
+    EOT
+    actions   = ["s3:GetObject"]
+    resources = ["arn:aws:s3:::bucket/$${aws:username}/*"]
+
+    condition {
+      test     = "NumericLessThan"
+      variable = "s3:max-keys"
+      values   = [10, true]
+    }
+  }
+}
+`
+	docs, err := ParseHCL([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"Statement":[{"Action":"s3:GetObject","Condition":{"NumericLessThan":{"s3:max-keys":["10","true"]}},"Effect":"Allow","Resource":"arn:aws:s3:::bucket/${aws:username}/*","Sid":"Heredoc\n"}],"Version":"2012-10-17"}`
+	if got := marshal(t, docs[0].Policy); got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+}
+
+func TestParseHCLPolicyVariables(t *testing.T) {
+	in := `data "aws_iam_policy_document" "p" {
+  statement {
+    actions   = ["s3:GetObject"]
+    resources = ["arn:aws:s3:::bucket/&{aws:username}/*", "arn:aws:s3:::bucket/$${aws:userid}/*"]
+
+    condition {
+      test     = "StringEquals"
+      variable = "s3:prefix"
+      values   = ["home/&{aws:username}/"]
+    }
+  }
+}
+`
+	docs, err := ParseHCL([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"Statement":[{"Action":"s3:GetObject","Condition":{"StringEquals":{"s3:prefix":"home/${aws:username}/"}},"Effect":"Allow","Resource":["arn:aws:s3:::bucket/${aws:username}/*","arn:aws:s3:::bucket/${aws:userid}/*"]}],"Version":"2012-10-17"}`
+	if got := marshal(t, docs[0].Policy); got != want {
+		t.Errorf("got '%s', want '%s'", got, want)
+	}
+}
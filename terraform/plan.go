@@ -0,0 +1,276 @@
+/*
+Package terraform reads the IAM policy documents out of the JSON form of
+Terraform plans and state, as written by [terraform show -json]:
+
+	terraform plan -out plan.tfplan
+	terraform show -json plan.tfplan > plan.json
+
+Policies appear in resources such as aws_iam_policy, aws_iam_role and
+aws_s3_bucket_policy as JSON strings. [Load] decodes the planned values of
+every resource, and [LoadChanges] decodes the values before and after each
+change, so that policy changes can be reviewed before they are applied.
+
+[FormatHCL] and [ParseHCL] convert between policies and
+aws_iam_policy_document data sources written in HCL. Only literal values are
+supported, so data sources that reference other resources or variables must
+be rendered by Terraform first.
+
+[terraform show -json]: https://developer.hashicorp.com/terraform/internals/json-format
+*/
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/micahhausler/aws-iam-policy/policy"
+)
+
+const (
+	ErrorNoValues = "plan has no planned_values or values"
+)
+
+// policyAttributes lists the attributes of each resource type that hold a
+// policy document.
+var policyAttributes = map[string][]string{
+	"aws_iam_policy":                   {"policy"},
+	"aws_iam_role":                     {"assume_role_policy"},
+	"aws_iam_role_policy":              {"policy"},
+	"aws_iam_user_policy":              {"policy"},
+	"aws_iam_group_policy":             {"policy"},
+	"aws_s3_bucket_policy":             {"policy"},
+	"aws_sqs_queue_policy":             {"policy"},
+	"aws_sqs_queue":                    {"policy"},
+	"aws_sns_topic_policy":             {"policy"},
+	"aws_sns_topic":                    {"policy"},
+	"aws_kms_key":                      {"policy"},
+	"aws_ecr_repository_policy":        {"policy"},
+	"aws_secretsmanager_secret_policy": {"policy"},
+}
+
+// Document is a policy document found in a plan.
+type Document struct {
+	// Address is the resource address, such as
+	// "module.app.aws_iam_role.this[0]".
+	Address string
+	// Type is the resource type, such as "aws_iam_role".
+	Type string
+	// Attribute is the path of the attribute that holds the policy, such as
+	// "assume_role_policy" or "inline_policy.0.policy".
+	Attribute string
+	// Policy is the decoded policy.
+	Policy *policy.Policy
+}
+
+// Change is a change to a policy document in a plan.
+type Change struct {
+	Address   string
+	Type      string
+	Attribute string
+	// Actions are the planned actions on the resource, such as ["update"] or
+	// ["delete", "create"].
+	Actions []string
+	// Before and After are the policy before and after the change. Before is
+	// nil for policies being created, and After is nil for policies being
+	// deleted or whose value is not known until apply.
+	Before *policy.Policy
+	After  *policy.Policy
+}
+
+// plan is the subset of the plan and state JSON formats read by this package.
+type plan struct {
+	PlannedValues   *values          `json:"planned_values"`
+	Values          *values          `json:"values"`
+	ResourceChanges []resourceChange `json:"resource_changes"`
+}
+
+type values struct {
+	RootModule module `json:"root_module"`
+}
+
+type module struct {
+	Resources    []resource `json:"resources"`
+	ChildModules []module   `json:"child_modules"`
+}
+
+type resource struct {
+	Address string                 `json:"address"`
+	Mode    string                 `json:"mode"`
+	Type    string                 `json:"type"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+type resourceChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Change  struct {
+		Actions []string               `json:"actions"`
+		Before  map[string]interface{} `json:"before"`
+		After   map[string]interface{} `json:"after"`
+	} `json:"change"`
+}
+
+// LoadFile reads the policies in a plan or state file.
+func LoadFile(name string) ([]*Document, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data)
+}
+
+// Load reads the policies in the planned values of a plan, or the values of
+// a state. Documents are returned in the order of the resources, with the
+// resources of child modules after those of their parent.
+func Load(data []byte) ([]*Document, error) {
+	p := &plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	v := p.PlannedValues
+	if v == nil {
+		v = p.Values
+	}
+	if v == nil {
+		return nil, errors.New(ErrorNoValues)
+	}
+	var docs []*Document
+	err := walkModule(v.RootModule, func(r resource) error {
+		if r.Mode == "data" {
+			return nil
+		}
+		found, err := resourcePolicies(r.Type, r.Values)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Address, err)
+		}
+		for _, attr := range attributes(found) {
+			docs = append(docs, &Document{Address: r.Address, Type: r.Type, Attribute: attr, Policy: found[attr]})
+		}
+		return nil
+	})
+	return docs, err
+}
+
+// LoadChangesFile reads the policy changes in a plan file.
+func LoadChangesFile(name string) ([]*Change, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return LoadChanges(data)
+}
+
+// LoadChanges reads the policy changes in a plan. Resources that are not
+// changed are skipped, as are policies that are equivalent before and after,
+// such as when only their key order differs. See policy.Equivalent.
+func LoadChanges(data []byte) ([]*Change, error) {
+	p := &plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	var changes []*Change
+	for _, rc := range p.ResourceChanges {
+		if rc.Mode == "data" || isNoOp(rc.Change.Actions) {
+			continue
+		}
+		before, err := resourcePolicies(rc.Type, rc.Change.Before)
+		if err != nil {
+			return nil, fmt.Errorf("%s: before: %w", rc.Address, err)
+		}
+		after, err := resourcePolicies(rc.Type, rc.Change.After)
+		if err != nil {
+			return nil, fmt.Errorf("%s: after: %w", rc.Address, err)
+		}
+		seen := map[string]*policy.Policy{}
+		for attr, p := range before {
+			seen[attr] = p
+		}
+		for attr, p := range after {
+			seen[attr] = p
+		}
+		for _, attr := range attributes(seen) {
+			c := &Change{
+				Address:   rc.Address,
+				Type:      rc.Type,
+				Attribute: attr,
+				Actions:   rc.Change.Actions,
+				Before:    before[attr],
+				After:     after[attr],
+			}
+			if c.Before != nil && c.After != nil && policy.Equivalent(c.Before, c.After) {
+				continue
+			}
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func isNoOp(actions []string) bool {
+	return len(actions) == 0 || (len(actions) == 1 && (actions[0] == "no-op" || actions[0] == "read"))
+}
+
+func walkModule(m module, fn func(resource) error) error {
+	for _, r := range m.Resources {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	for _, child := range m.ChildModules {
+		if err := walkModule(child, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourcePolicies decodes the policies in the values of a resource, keyed by
+// attribute path. Attributes that are empty or not yet known are skipped.
+func resourcePolicies(resourceType string, values map[string]interface{}) (map[string]*policy.Policy, error) {
+	found := map[string]*policy.Policy{}
+	if values == nil {
+		return found, nil
+	}
+	add := func(attr string, value interface{}) error {
+		s, ok := value.(string)
+		if !ok || s == "" {
+			return nil
+		}
+		p, err := policy.Unmarshal([]byte(s), policy.PreserveKeyOrder())
+		if err != nil {
+			return fmt.Errorf("%s: %w", attr, err)
+		}
+		found[attr] = p
+		return nil
+	}
+	for _, attr := range policyAttributes[resourceType] {
+		if err := add(attr, values[attr]); err != nil {
+			return nil, err
+		}
+	}
+	if resourceType == "aws_iam_role" {
+		inline, _ := values["inline_policy"].([]interface{})
+		for i, item := range inline {
+			block, _ := item.(map[string]interface{})
+			if err := add("inline_policy."+strconv.Itoa(i)+".policy", block["policy"]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return found, nil
+}
+
+// attributes returns the attribute paths of found policies in sorted order.
+func attributes(found map[string]*policy.Policy) []string {
+	attrs := make([]string, 0, len(found))
+	for attr := range found {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	return attrs
+}
@@ -0,0 +1,89 @@
+package terraform
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func marshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(b)
+}
+
+func TestLoadFile(t *testing.T) {
+	docs, err := LoadFile("testdata/plan.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`aws_iam_role.app aws_iam_role assume_role_policy {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+		`aws_iam_role.app aws_iam_role inline_policy.0.policy {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"logs:PutLogEvents","Resource":"*"}]}`,
+		`module.bucket.aws_s3_bucket_policy.this aws_s3_bucket_policy policy {"Version":"2012-10-17","Statement":[{"Sid":"DenyHTTP","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::logs/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`,
+	}
+	if len(docs) != len(want) {
+		t.Fatalf("got %d documents, want %d", len(docs), len(want))
+	}
+	for i, doc := range docs {
+		got := strings.Join([]string{doc.Address, doc.Type, doc.Attribute, marshal(t, doc.Policy)}, " ")
+		if got != want[i] {
+			t.Errorf("got '%s', want '%s'", got, want[i])
+		}
+	}
+}
+
+func TestLoadChangesFile(t *testing.T) {
+	changes, err := LoadChangesFile("testdata/plan.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`aws_iam_role.app inline_policy.0.policy [update] {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"logs:*","Resource":"*"}]} {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"logs:PutLogEvents","Resource":"*"}]}`,
+		`module.bucket.aws_s3_bucket_policy.this policy [create] null {"Version":"2012-10-17","Statement":[{"Sid":"DenyHTTP","Effect":"Deny","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::logs/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`,
+		`module.bucket.aws_iam_policy.old policy [delete] {"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"s3:*","Resource":"*"}} null`,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(changes), len(want))
+	}
+	for i, c := range changes {
+		got := strings.Join([]string{c.Address, c.Attribute, "[" + strings.Join(c.Actions, ",") + "]", marshal(t, c.Before), marshal(t, c.After)}, " ")
+		if got != want[i] {
+			t.Errorf("got '%s', want '%s'", got, want[i])
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "NoValues",
+			in:   `{"format_version": "1.2"}`,
+			want: ErrorNoValues,
+		},
+		{
+			name: "InvalidPolicy",
+			in:   `{"values": {"root_module": {"resources": [{"address": "aws_iam_policy.p", "mode": "managed", "type": "aws_iam_policy", "values": {"policy": "{\"Statement\": 1}"}}]}}}`,
+			want: "aws_iam_policy.p: policy: line 1, column 15: /Statement: unexpected JSON type: expected object or array, got number",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load([]byte(tc.in))
+			if err == nil {
+				t.Fatalf("expected error '%s', got none", tc.want)
+			}
+			if err.Error() != tc.want {
+				t.Errorf("got '%s', want '%s'", err.Error(), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+data "aws_iam_policy_document" "bucket" {
+  policy_id = "BucketPolicy"
+  version   = "2012-10-17"
+
+  statement {
+    sid       = "ReadOwnPrefix"
+    effect    = "Allow"
+    actions   = ["s3:GetObject"]
+    resources = ["arn:aws:s3:::examplebucket/home/$${aws:username}/*"]
+
+    principals {
+      type = "AWS"
+      identifiers = [
+        "arn:aws:iam::111122223333:root",
+        "arn:aws:iam::444455556666:root",
+      ]
+    }
+
+    condition {
+      test     = "NumericLessThanEquals"
+      variable = "s3:max-keys"
+      values   = ["10"]
+    }
+  }
+
+  statement {
+    sid         = "DenyHTTP"
+    effect      = "Deny"
+    not_actions = ["s3:ListBucket"]
+    resources   = ["*"]
+
+    principals {
+      type        = "*"
+      identifiers = ["*"]
+    }
+
+    condition {
+      test     = "Bool"
+      variable = "aws:SecureTransport"
+      values   = ["false"]
+    }
+  }
+}